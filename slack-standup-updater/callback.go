@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// defaultCallbackPort must match the redirect URL registered for the app
+	defaultCallbackPort = 1337
+
+	// callbackTimeout is how long to wait for the browser to come back
+	callbackTimeout = 5 * time.Minute
+
+	// callbackShutdownTimeout lets the success page finish sending
+	callbackShutdownTimeout = 5 * time.Second
+)
+
+const callbackSuccessPage = `
+			<!DOCTYPE html>
+			<html>
+			<head>
+				<title>Authentication Successful</title>
+				<style>
+					body { font-family: Arial, sans-serif; text-align: center; padding: 50px; }
+					.success { color: green; }
+				</style>
+			</head>
+			<body>
+				<h1 class="success">Authentication Successful!</h1>
+				<p>You can now close this window and return to the terminal.</p>
+			</body>
+			</html>
+		`
+
+// callbackResult is the outcome of the one accepted callback
+type callbackResult struct {
+	config TokenConfig
+	err    error
+}
+
+// callbackServer receives the OAuth redirect on the loopback interface only.
+// It has its own mux instead of http.DefaultServeMux and accepts a single
+// callback with the right state; anything after that is turned away.
+type callbackServer struct {
+	server    *http.Server
+	listeners []net.Listener
+	results   chan callbackResult
+	accepted  sync.Once
+}
+
+// startCallbackServer listens on 127.0.0.1 (and ::1 where available) and
+// serves the callback in the background. The port comes from a localhost
+// callback URL, otherwise from port (e.g. behind an ngrok tunnel).
+func startCallbackServer(p *prompter, req authRequest, port int) (*callbackServer, error) {
+	callback, err := url.Parse(req.callbackURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid callback URL: %v", err)
+	}
+
+	local := isLoopbackHost(callback.Hostname())
+	if local && callback.Port() != "" {
+		if port, err = strconv.Atoi(callback.Port()); err != nil {
+			return nil, fmt.Errorf("invalid callback port %q", callback.Port())
+		}
+	}
+
+	s := &callbackServer{
+		// Buffered so the handler never blocks once nobody is waiting
+		results: make(chan callbackResult, 1),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(callback.Path, func(w http.ResponseWriter, r *http.Request) {
+		s.handleCallback(p, req, w, r)
+	})
+	s.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Only a certificate for localhost makes sense; a public https URL such
+	// as ngrok terminates TLS itself and forwards plain HTTP
+	useTLS := callback.Scheme == "https" && local
+	if useTLS {
+		cert, err := localhostCertificate(p)
+		if err != nil {
+			return nil, err
+		}
+		s.server.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	}
+
+	// 127.0.0.1 is required; ::1 is added when the system has IPv6 loopback,
+	// since browsers may resolve localhost to either
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		if errors.Is(err, syscall.EADDRINUSE) || strings.Contains(err.Error(), "address already in use") {
+			return nil, fmt.Errorf("port %d is already in use, probably by another sign-in; finish that one or use --port (the redirect URL of your app must match)", port)
+		}
+		return nil, fmt.Errorf("listening on %s: %v", addr, err)
+	}
+	s.listeners = append(s.listeners, listener)
+	if listener6, err := net.Listen("tcp", net.JoinHostPort("::1", strconv.Itoa(port))); err == nil {
+		s.listeners = append(s.listeners, listener6)
+	}
+
+	scheme := "HTTP"
+	if useTLS {
+		scheme = "HTTPS"
+	}
+	p.printInfo(fmt.Sprintf("Starting %s server on localhost port %d...", scheme, port))
+
+	for _, l := range s.listeners {
+		go func(l net.Listener) {
+			var err error
+			if useTLS {
+				err = s.server.ServeTLS(l, "", "")
+			} else {
+				err = s.server.Serve(l)
+			}
+			if err != nil && err != http.ErrServerClosed {
+				s.finish(callbackResult{err: fmt.Errorf("server error: %v", err)})
+			}
+		}(l)
+	}
+
+	return s, nil
+}
+
+// handleCallback checks and exchanges the redirect from Slack
+func (s *callbackServer) handleCallback(p *prompter, req authRequest, w http.ResponseWriter, r *http.Request) {
+	// Check state to prevent CSRF. A stray request is refused but does not
+	// end the sign-in, so the real redirect can still arrive.
+	if subtle.ConstantTimeCompare([]byte(r.FormValue("state")), []byte(req.state)) != 1 {
+		p.printError("Ignored a callback with an invalid state parameter")
+		http.Error(w, "Invalid state parameter", http.StatusBadRequest)
+		return
+	}
+
+	accepted := false
+	s.accepted.Do(func() { accepted = true })
+	if !accepted {
+		http.Error(w, "This sign-in has already been completed", http.StatusGone)
+		return
+	}
+
+	// Check for error
+	if r.FormValue("error") != "" {
+		s.finish(callbackResult{err: fmt.Errorf("authorization error: %s", r.FormValue("error"))})
+		http.Error(w, fmt.Sprintf("Authorization error: %s", r.FormValue("error")), http.StatusBadRequest)
+		return
+	}
+
+	// Get authorization code
+	code := r.FormValue("code")
+	if code == "" {
+		s.finish(callbackResult{err: fmt.Errorf("no code provided")})
+		http.Error(w, "No code provided", http.StatusBadRequest)
+		return
+	}
+
+	// Exchange code for token
+	config, err := req.exchange(code)
+	if err != nil {
+		s.finish(callbackResult{err: err})
+		http.Error(w, "Failed to exchange code for token", http.StatusInternalServerError)
+		return
+	}
+
+	// Return success page, then hand over the token
+	w.Header().Set("Content-Type", "text/html")
+	w.Write([]byte(callbackSuccessPage))
+	s.finish(callbackResult{config: config})
+}
+
+// finish reports the first result; later ones are dropped
+func (s *callbackServer) finish(result callbackResult) {
+	select {
+	case s.results <- result:
+	default:
+	}
+}
+
+// shutdown stops the server gracefully, letting the response in flight finish
+func (s *callbackServer) shutdown(p *prompter) {
+	ctx, cancel := context.WithTimeout(context.Background(), callbackShutdownTimeout)
+	defer cancel()
+
+	if err := s.server.Shutdown(ctx); err != nil {
+		p.printInfo(fmt.Sprintf("Warning: Callback server did not stop cleanly: %v", err))
+		s.server.Close()
+	}
+}
+
+// isLoopbackHost reports whether a callback host name points at this machine
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
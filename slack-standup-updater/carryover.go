@@ -0,0 +1,314 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// carryOverWindow is how far back Slack is searched for the last standup
+// when the local history has none
+const carryOverWindow = 7 * 24 * time.Hour
+
+// checklistItemRe matches a bullet point marked with its outcome, e.g.
+// "[x] Ship the importer"
+var checklistItemRe = regexp.MustCompile(`^\[([ xX~>-])\]\s+(.*)$`)
+
+// Checklist markers for the outcome of an item carried over
+const (
+	checklistOpen    = "[ ] "
+	checklistDone    = "[x] "
+	checklistPartial = "[~] "
+	checklistDropped = "[-] "
+	checklistCarried = "[>] "
+)
+
+// renderChecklistItem turns a marked bullet point into what is posted;
+// anything else is returned unchanged
+func renderChecklistItem(line string) string {
+	match := checklistItemRe.FindStringSubmatch(line)
+	if match == nil {
+		return line
+	}
+	text := match[2]
+	switch match[1] {
+	case "x", "X":
+		return "✅ " + text
+	case "~":
+		return "🔶 " + text + " (partly done)"
+	case "-":
+		return "❌ ~" + text + "~ (dropped)"
+	case ">":
+		return "➡️ " + text + " (carried over)"
+	}
+	return text
+}
+
+// previousStandup is the answer to be carried over, and when it was given
+type previousStandup struct {
+	items  []string
+	posted time.Time
+}
+
+// findPreviousStandup returns the bullet points of the last standup's answer
+// to question from, posted before today. The local history is used when it
+// has one from the same profile, the latest one to the same channel first;
+// otherwise the user's own last standup is read from the destination. A
+// second post today, such as a fix-up, is never taken as the previous one.
+func findPreviousStandup(p *prompter, api *slack.Client, questions []Question, from, profile, channelID, threadTS string) (previousStandup, bool) {
+	today := startOfDay(time.Now())
+
+	entries, _, err := loadHistory()
+	if err == nil {
+		var latest, sameChannel *HistoryEntry
+		for i := len(entries) - 1; i >= 0 && sameChannel == nil; i-- {
+			entry := &entries[i]
+			if entry.Profile != profile || !time.Unix(entry.Posted, 0).Before(today) {
+				continue
+			}
+			if latest == nil {
+				latest = entry
+			}
+			if entry.ChannelID == channelID {
+				sameChannel = entry
+			}
+		}
+		if sameChannel != nil {
+			latest = sameChannel
+		}
+		if latest != nil {
+			return previousStandup{items: checklistItems(latest.answer(from)), posted: time.Unix(latest.Posted, 0)}, true
+		}
+	}
+
+	// Reading DMs and group DMs would need im:history and mpim:history, which
+	// are not requested, so for those only the local history is used
+	if isDirectMessage(api, channelID) {
+		return previousStandup{}, false
+	}
+
+	var heading string
+	for _, q := range questions {
+		if q.ID == from {
+			heading = q.heading()
+		}
+	}
+
+	info, err := identity(api)
+	if err != nil {
+		p.printInfo(fmt.Sprintf("Warning: Could not read your last standup from Slack: %v", err))
+		return previousStandup{}, false
+	}
+	msg, found, err := lastOwnStandup(api, channelID, threadTS, info.UserID, heading, time.Now())
+	if err != nil {
+		p.printInfo(fmt.Sprintf("Warning: Could not read your last standup from Slack: %v", err))
+		return previousStandup{}, false
+	}
+	if !found {
+		return previousStandup{}, false
+	}
+
+	seconds, _, _ := strings.Cut(msg.Timestamp, ".")
+	unix, _ := strconv.ParseInt(seconds, 10, 64)
+	return previousStandup{items: checklistItems(messageSection(msg.Text, heading)), posted: time.Unix(unix, 0)}, true
+}
+
+// isDirectMessage reports whether a conversation is a DM or group DM. DM IDs
+// start with D; group DMs can have a G or C ID like channels, so those are
+// looked up with conversations.info.
+func isDirectMessage(api *slack.Client, channelID string) bool {
+	if strings.HasPrefix(channelID, "D") {
+		return true
+	}
+	info, err := api.GetConversationInfo(&slack.GetConversationInfoInput{ChannelID: channelID})
+	return err == nil && (info.IsIM || info.IsMpIM)
+}
+
+// lastOwnStandup looks for the user's latest message containing heading:
+// in the thread being replied to, among the channel's messages, and in the
+// threads of the channel the user replied in. Only the last week before
+// today is read.
+func lastOwnStandup(api *slack.Client, channelID, threadTS, userID, heading string, now time.Time) (slack.Message, bool, error) {
+	today := startOfDay(now).Unix()
+	isStandup := func(msg slack.Message) bool {
+		seconds, _, _ := strings.Cut(msg.Timestamp, ".")
+		posted, _ := strconv.ParseInt(seconds, 10, 64)
+		return msg.User == userID && posted < today && messageSection(msg.Text, heading) != ""
+	}
+
+	// The newest reply of a thread comes last
+	latestReply := func(ts string) (slack.Message, bool, error) {
+		replies, _, _, err := api.GetConversationReplies(&slack.GetConversationRepliesParameters{ChannelID: channelID, Timestamp: ts, Limit: 200})
+		if err != nil {
+			return slack.Message{}, false, fmt.Errorf("reading thread: %v%s", err, scopeHint(err, "channels:history and groups:history"))
+		}
+		for i := len(replies) - 1; i >= 0; i-- {
+			if isStandup(replies[i]) {
+				return replies[i], true, nil
+			}
+		}
+		return slack.Message{}, false, nil
+	}
+
+	if threadTS != "" {
+		if msg, found, err := latestReply(threadTS); err != nil || found {
+			return msg, found, err
+		}
+	}
+
+	history, err := api.GetConversationHistory(&slack.GetConversationHistoryParameters{
+		ChannelID: channelID,
+		Oldest:    strconv.FormatInt(now.Add(-carryOverWindow).Unix(), 10) + ".000000",
+		Limit:     200,
+	})
+	if err != nil {
+		return slack.Message{}, false, fmt.Errorf("reading channel history: %v%s", err, scopeHint(err, "channels:history and groups:history"))
+	}
+
+	// Messages come newest first
+	for _, msg := range history.Messages {
+		if isStandup(msg) {
+			return msg, true, nil
+		}
+		if msg.Timestamp == threadTS {
+			continue
+		}
+		for _, user := range msg.ReplyUsers {
+			if user != userID {
+				continue
+			}
+			if reply, found, err := latestReply(msg.Timestamp); err != nil || found {
+				return reply, found, err
+			}
+			break
+		}
+	}
+	return slack.Message{}, false, nil
+}
+
+// messageSection returns the lines under a heading in a posted standup, up
+// to the blank line ending the section
+func messageSection(text, heading string) string {
+	heading = strings.TrimSpace(heading)
+	if heading == "" {
+		return ""
+	}
+
+	var lines []string
+	inSection := false
+	for _, line := range strings.Split(text, "\n") {
+		if !inSection {
+			inSection = strings.TrimSpace(line) == heading
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+		lines = append(lines, line)
+	}
+
+	// Slack escapes these three in message text
+	section := strings.Join(lines, "\n")
+	return strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&").Replace(section)
+}
+
+// checklistItems cuts an answer into bullet points without their "- " and
+// any outcome marker, ready to be marked again
+func checklistItems(answer string) []string {
+	var items []string
+	for _, bullet := range splitBullets(answer) {
+		if strings.HasPrefix(bullet, "- ") || strings.HasPrefix(bullet, "* ") {
+			bullet = bullet[2:]
+		}
+		if match := checklistItemRe.FindStringSubmatch(bullet); match != nil {
+			bullet = match[2]
+		}
+		if bullet = strings.TrimSpace(bullet); bullet != "" {
+			items = append(items, bullet)
+		}
+	}
+	return items
+}
+
+// carryOver prefills every question with carry_over set with the items of
+// the previous standup's answer to the question it names. With ask, each
+// item is marked done, partly done, dropped or carried over here, and the
+// carried over ones also prefill the question they came from; otherwise
+// they are left open, to be marked in the editor. Questions in skip are
+// answered already; profile is the profile posting, as recorded in history.
+func carryOver(p *prompter, api *slack.Client, questions []Question, skip map[string]bool, profile, channelID, threadTS string, ask bool) (map[string]string, error) {
+	prefill := make(map[string]string)
+
+	for _, q := range questions {
+		if q.CarryOver == "" || skip[q.ID] {
+			continue
+		}
+		previous, found := findPreviousStandup(p, api, questions, q.CarryOver, profile, channelID, threadTS)
+		if !found || len(previous.items) == 0 {
+			continue
+		}
+
+		if !ask {
+			var lines []string
+			for _, item := range previous.items {
+				lines = append(lines, checklistOpen+item)
+			}
+			prefill[q.ID] = strings.Join(lines, "\n")
+			continue
+		}
+
+		marked, carried, err := markChecklist(p, previous)
+		if err != nil {
+			return nil, err
+		}
+		prefill[q.ID] = strings.Join(marked, "\n")
+		if len(carried) > 0 && !skip[q.CarryOver] {
+			prefill[q.CarryOver] = strings.Join(carried, "\n")
+		}
+	}
+	return prefill, nil
+}
+
+// markChecklist asks how each item of the previous standup went and returns
+// the marked items, and the ones carried over
+func markChecklist(p *prompter, previous previousStandup) ([]string, []string, error) {
+	p.printHeader("Last Standup's Plan ✅")
+	p.printInfo(fmt.Sprintf("From your standup of %s, how did these go?", previous.posted.Format("Mon Jan 2")))
+
+	var marked, carried []string
+	for _, item := range previous.items {
+		p.printDivider()
+		fmt.Fprintln(p.writer, item)
+		p.printInfo("[d]one, [p]artly done, d[r]opped or [c]arried over? (Enter for done)")
+
+		for {
+			answer, err := p.choose()
+			if err != nil {
+				return nil, nil, err
+			}
+
+			var marker string
+			switch strings.ToLower(answer) {
+			case "", "d", "done":
+				marker = checklistDone
+			case "p", "partly", "partial":
+				marker = checklistPartial
+			case "r", "dropped", "drop":
+				marker = checklistDropped
+			case "c", "carried", "carry":
+				marker = checklistCarried
+				carried = append(carried, item)
+			default:
+				p.printError("Enter d, p, r or c")
+				continue
+			}
+			marked = append(marked, marker+item)
+			break
+		}
+	}
+	return marked, carried, nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// certsDir lives inside the config directory, never the working directory
+	certsDir     = "certs"
+	certFile     = "cert.pem"
+	keyFile      = "key.pem"
+	certValidity = 365 * 24 * time.Hour
+
+	// certRenewBefore replaces a certificate this long before it expires so
+	// a login never starts with one that is about to lapse
+	certRenewBefore = 24 * time.Hour
+)
+
+// localhostCertificate returns the self-signed certificate for the local
+// OAuth callback, generating a new one when none exists or it is expiring
+func localhostCertificate(p *prompter) (tls.Certificate, error) {
+	dir, err := configPath(certsDir)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	certPath := filepath.Join(dir, certFile)
+	keyPath := filepath.Join(dir, keyFile)
+
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err == nil && time.Until(cert.Leaf.NotAfter) > certRenewBefore {
+		p.printInfo("Using saved localhost certificate, SHA-256 fingerprint:")
+		p.printInfo(certFingerprint(cert.Leaf.Raw))
+		return cert, nil
+	}
+
+	p.printInfo("Generating a self-signed certificate for local HTTPS...")
+	certPEM, keyPEM, err := generateLocalhostCert(time.Now())
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("generating certificate: %v", err)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return tls.Certificate{}, fmt.Errorf("creating certs directory: %v", err)
+	}
+	if err := writeFileAtomic(keyPath, keyPEM, 0600); err != nil {
+		return tls.Certificate{}, fmt.Errorf("saving certificate key: %v", err)
+	}
+	if err := writeFileAtomic(certPath, certPEM, 0600); err != nil {
+		return tls.Certificate{}, fmt.Errorf("saving certificate: %v", err)
+	}
+
+	cert, err = tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	p.printSuccess("Certificate generated, SHA-256 fingerprint:")
+	p.printInfo(certFingerprint(cert.Leaf.Raw))
+	p.printInfo("Your browser will warn about it once; check the fingerprint matches before accepting.")
+	return cert, nil
+}
+
+// generateLocalhostCert creates a PEM encoded ECDSA key and a certificate for
+// localhost, 127.0.0.1 and ::1 that is valid from now for certValidity
+func generateLocalhostCert(now time.Time) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "localhost", Organization: []string{"Slack Standup Updater"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(certValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
+
+// certFingerprint formats the SHA-256 fingerprint the way browsers show it
+func certFingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const usageText = `Usage: standup [command] [flags]
+
+Commands:
+  post     Post a standup (default when no command is given)
+  login    Sign in to Slack (again) for a profile
+  logout   Revoke a profile's token and remove its stored credentials
+  profile  Manage credential profiles for Slack workspaces
+  lock     Forget the unlocked passphrase of encrypted credentials
+  history  List the standups posted so far
+  show     Show a posted standup by ID or date
+  help     Show this help
+
+Run "standup <command> -h" for the flags of a command.
+`
+
+// runCommand dispatches to the subcommand named by the first argument
+func runCommand(p *prompter, args []string) error {
+	// Plain `standup` or `standup --flag ...` keeps working as a post
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return runPost(p, args)
+	}
+
+	switch args[0] {
+	case "post":
+		return runPost(p, args[1:])
+	case "login":
+		return runLogin(p, args[1:])
+	case "logout":
+		return runLogout(p, args[1:])
+	case "profile":
+		return runProfile(p, args[1:])
+	case "lock":
+		return runLock(p, args[1:])
+	case "history":
+		return runHistory(p, args[1:])
+	case "show":
+		return runShow(p, args[1:])
+	case "help":
+		fmt.Fprint(p.writer, usageText)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, usageText)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// answerFlag collects the bullet points given for one question; it can be
+// repeated, and each value may also contain several newline separated lines
+type answerFlag struct {
+	lines []string
+	set   bool
+}
+
+func (a *answerFlag) String() string {
+	return strings.Join(a.lines, "\n")
+}
+
+func (a *answerFlag) Set(value string) error {
+	a.set = true
+	for _, line := range strings.Split(value, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			a.lines = append(a.lines, line)
+		}
+	}
+	return nil
+}
+
+// answerAssignment implements --answer id=text for any configured question
+type answerAssignment map[string]*answerFlag
+
+func (a answerAssignment) String() string {
+	return ""
+}
+
+func (a answerAssignment) Set(value string) error {
+	id, text, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("expected id=text, got %q", value)
+	}
+	answer, ok := a[strings.TrimSpace(id)]
+	if !ok {
+		return fmt.Errorf("no question with id %q", id)
+	}
+	return answer.Set(text)
+}
+
+// postOptions holds everything `standup post` can be told up front
+type postOptions struct {
+	profile string
+	login   loginOptions
+	channel string
+	thread  string
+	link    string
+	to      string
+	yes     bool
+	review  bool
+	editor  bool
+	answers map[string]*answerFlag // Keyed by question ID
+}
+
+// hasAllAnswers reports whether every question was answered by a flag
+func (o postOptions) hasAllAnswers() bool {
+	for _, answer := range o.answers {
+		if !answer.set {
+			return false
+		}
+	}
+	return true
+}
+
+// hasDestination reports whether where to post was given by a flag
+func (o postOptions) hasDestination() bool {
+	return o.channel != "" || o.link != "" || o.to != ""
+}
+
+// hasAnyAnswer reports whether any question was answered by a flag
+func (o postOptions) hasAnyAnswer() bool {
+	for _, answer := range o.answers {
+		if answer.set {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePostFlags parses the flags of `standup post`. Every configured
+// question gets a flag named after its ID, e.g. --yesterday.
+func parsePostFlags(args []string, questions []Question) (postOptions, error) {
+	opts := postOptions{answers: make(map[string]*answerFlag)}
+
+	fs := flag.NewFlagSet("post", flag.ContinueOnError)
+	fs.StringVar(&opts.profile, "profile", "", "credential profile to post with (default: the default profile)")
+	opts.login.addFlags(fs)
+	fs.StringVar(&opts.channel, "channel", "", "channel, DM or group ID to post to (e.g. C048ECCB75H)")
+	fs.StringVar(&opts.thread, "thread", "", "thread timestamp to reply to (e.g. 1743724813.501239), or \"auto\" for today's standup thread")
+	fs.StringVar(&opts.link, "link", "", "Slack message link of the thread to reply to")
+	fs.StringVar(&opts.to, "to", "", "named destination from config.json to post to")
+	fs.BoolVar(&opts.yes, "yes", false, "post without the review step")
+	fs.BoolVar(&opts.review, "review", false, "review the standup even when flags answer everything")
+	fs.BoolVar(&opts.editor, "editor", false, "write the standup in $VISUAL or $EDITOR as a markdown document")
+	fs.Var(answerAssignment(opts.answers), "answer", "answer a question by ID, as id=text (repeatable)")
+
+	for _, q := range questions {
+		opts.answers[q.ID] = &answerFlag{}
+		// IDs that clash with a built-in flag are still reachable through --answer
+		if fs.Lookup(q.ID) != nil {
+			continue
+		}
+		usage := fmt.Sprintf("answer to %q (repeatable, \"\" for none)", q.Prompt)
+		if q.Required {
+			usage = fmt.Sprintf("answer to %q (repeatable)", q.Prompt)
+		}
+		fs.Var(opts.answers[q.ID], q.ID, usage)
+	}
+
+	fs.Usage = func() {
+		printFlagUsage(fs.Output(), "standup post [flags]", fs)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return opts, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	if opts.to != "" && (opts.link != "" || opts.channel != "" || opts.thread != "") {
+		return opts, fmt.Errorf("--to cannot be combined with --link, --channel or --thread")
+	}
+	if opts.link != "" && (opts.channel != "" || opts.thread != "") {
+		return opts, fmt.Errorf("--link cannot be combined with --channel or --thread")
+	}
+	if opts.thread != "" && opts.channel == "" {
+		return opts, fmt.Errorf("--thread requires --channel")
+	}
+
+	return opts, nil
+}
+
+// printFlagUsage prints a usage line followed by the defaults of a flag set
+func printFlagUsage(w io.Writer, usage string, fs *flag.FlagSet) {
+	fmt.Fprintf(w, "Usage: %s\n\nFlags:\n", usage)
+	fs.PrintDefaults()
+}
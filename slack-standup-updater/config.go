@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// settingsFile holds user preferences, kept apart from the credentials file
+const settingsFile = "config.json"
+
+// stateFile holds what the tool remembers between runs, so config.json is
+// only ever written by the user
+const stateFile = "state.json"
+
+// Question is one entry of the standup, in the order it is asked
+type Question struct {
+	ID       string `json:"id"`
+	Prompt   string `json:"prompt"`
+	Heading  string `json:"heading,omitempty"`  // Defaults to the prompt
+	Required bool   `json:"required,omitempty"` // Needs at least one bullet point; others may be left blank
+
+	// CarryOver names the question whose answer in the previous standup is
+	// offered here as a checklist, e.g. "today" for the "yesterday" question
+	CarryOver string `json:"carry_over,omitempty"`
+
+	// Suggest names a provider of suggested bullet points: "git-commits" or
+	// "git-branches"
+	Suggest string `json:"suggest,omitempty"`
+}
+
+// Settings is the user editable config.json
+type Settings struct {
+	Questions []Question `json:"questions,omitempty"`
+
+	// UseEditor writes the whole standup in $VISUAL or $EDITOR instead of
+	// answering one question at a time
+	UseEditor bool `json:"use_editor,omitempty"`
+
+	// Destinations are named places to post to, selected with --to
+	Destinations map[string]Destination `json:"destinations,omitempty"`
+
+	// Git lists the repositories suggestions are gathered from
+	Git GitSettings `json:"git,omitempty"`
+
+	// EncryptCredentials keeps token.json encrypted with a passphrase
+	EncryptCredentials bool `json:"encrypt_credentials,omitempty"`
+	// UnlockCacheMinutes is how long a passphrase is remembered (default 15, 0 to always ask)
+	UnlockCacheMinutes *int `json:"unlock_cache_minutes,omitempty"`
+}
+
+// defaultQuestions is the classic three question standup
+var defaultQuestions = []Question{
+	{ID: "yesterday", Prompt: "1. What did you do yesterday?", CarryOver: "today", Suggest: suggestGitCommits},
+	{ID: "today", Prompt: "2. What will you do today?", Suggest: suggestGitBranches},
+	{ID: "blockers", Prompt: "3. Anything blocking your progress?"},
+}
+
+// heading returns the text used for the question's section of the message
+func (q Question) heading() string {
+	if q.Heading != "" {
+		return q.Heading
+	}
+	return q.Prompt
+}
+
+// configPath returns the path of a file inside the config directory
+func configPath(name string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, configDir, name), nil
+}
+
+// loadSettings reads config.json, falling back to defaults when it is missing
+func loadSettings() (Settings, error) {
+	var settings Settings
+
+	path, err := configPath(settingsFile)
+	if err != nil {
+		return settings, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return settings, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return settings, fmt.Errorf("parsing %s: %v", path, err)
+		}
+	}
+
+	if len(settings.Questions) == 0 {
+		settings.Questions = defaultQuestions
+	}
+	if err := validateQuestions(settings.Questions); err != nil {
+		return settings, fmt.Errorf("%s: %v", path, err)
+	}
+	for name, dest := range settings.Destinations {
+		if err := dest.validate(); err != nil {
+			return settings, fmt.Errorf("%s: destination %q: %v", path, name, err)
+		}
+	}
+
+	return settings, nil
+}
+
+// validateQuestions makes sure every question can be addressed and asked
+func validateQuestions(questions []Question) error {
+	seen := make(map[string]bool)
+	for i, q := range questions {
+		if q.ID == "" {
+			return fmt.Errorf("question %d has no id", i+1)
+		}
+		if q.Prompt == "" {
+			return fmt.Errorf("question %q has no prompt", q.ID)
+		}
+		if seen[q.ID] {
+			return fmt.Errorf("question id %q is used more than once", q.ID)
+		}
+		seen[q.ID] = true
+	}
+
+	for _, q := range questions {
+		if q.CarryOver == "" {
+			continue
+		}
+		if q.CarryOver == q.ID {
+			return fmt.Errorf("question %q cannot carry over its own answer", q.ID)
+		}
+		if !seen[q.CarryOver] {
+			return fmt.Errorf("question %q carries over from %q, which is not a question", q.ID, q.CarryOver)
+		}
+	}
+
+	for _, q := range questions {
+		if q.Suggest != "" && !suggestProviders[q.Suggest] {
+			return fmt.Errorf("question %q: unknown suggest %q", q.ID, q.Suggest)
+		}
+	}
+	return nil
+}
+
+// State is what the tool remembers between runs
+type State struct {
+	LastDestination string `json:"last_destination,omitempty"`
+
+	// Usage counts the posts per destination ("to:<name>") or channel ID,
+	// so the picker can offer the likeliest ones first
+	Usage map[string]Usage `json:"usage,omitempty"`
+}
+
+// Usage is how often and how recently something was posted to
+type Usage struct {
+	Count int   `json:"count"`
+	Last  int64 `json:"last"`
+}
+
+// loadState reads state.json; a missing or unreadable file is an empty state
+func loadState() State {
+	var state State
+
+	path, err := configPath(stateFile)
+	if err != nil {
+		return state
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return state
+	}
+	json.Unmarshal(data, &state)
+	return state
+}
+
+// saveState writes state.json
+func saveState(state State) error {
+	path, err := configPath(stateFile)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0600)
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// Destination is a named place to post the standup to, set up once in
+// config.json. Exactly one of Channel, Link or User is given.
+type Destination struct {
+	Channel string `json:"channel,omitempty"` // Channel name or ID
+	Thread  string `json:"thread,omitempty"`  // Thread timestamp to reply to in Channel, or "auto"
+	Link    string `json:"link,omitempty"`    // Slack message link of the thread to reply to
+	User    string `json:"user,omitempty"`    // User (@handle, name, email or ID) to send a direct message to
+
+	// Find tells how to recognise today's thread when Thread is "auto"
+	Find *ThreadFinder `json:"find,omitempty"`
+}
+
+// validate checks that a destination says unambiguously where to post
+func (d Destination) validate() error {
+	set := 0
+	for _, value := range []string{d.Channel, d.Link, d.User} {
+		if value != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("needs exactly one of channel, link or user")
+	}
+	if d.Thread != "" && d.Channel == "" {
+		return fmt.Errorf("thread requires channel")
+	}
+	if d.Link != "" {
+		if _, err := parseSlackLink(d.Link); err != nil {
+			return err
+		}
+	}
+	if d.Find != nil {
+		if d.Thread != autoThread {
+			return fmt.Errorf("find requires thread \"auto\"")
+		}
+		return d.Find.validate()
+	}
+	return nil
+}
+
+// describe says what posting to the destination does
+func (d Destination) describe() string {
+	switch {
+	case d.User != "":
+		return "direct message to " + d.User
+	case d.Link != "":
+		return "reply in the thread of " + d.Link
+	case d.Thread == autoThread:
+		return "reply in today's standup thread of " + d.Channel
+	case d.Thread != "":
+		return fmt.Sprintf("reply in thread %s of %s", d.Thread, d.Channel)
+	default:
+		return "new message in " + d.Channel
+	}
+}
+
+// resolveDestination turns a destination into the channel ID and, for
+// thread replies, the thread timestamp to post with
+func resolveDestination(p *prompter, dir *directory, d Destination) (string, string, error) {
+	switch {
+	case d.User != "":
+		userID, err := dir.userID(d.User)
+		if err != nil {
+			return "", "", err
+		}
+
+		// Open a conversation with this user
+		channel, _, _, err := dir.api.OpenConversation(&slack.OpenConversationParameters{
+			Users: []string{userID},
+		})
+		if err != nil {
+			return "", "", fmt.Errorf("opening conversation with user %s: %v", d.User, err)
+		}
+		return channel.ID, "", nil
+	case d.Link != "":
+		link, err := parseSlackLink(d.Link)
+		if err != nil {
+			return "", "", fmt.Errorf("parsing Slack link: %v", err)
+		}
+		return link.ChannelID, link.ThreadTS, nil
+	case d.Thread == autoThread:
+		channelID, err := dir.channelID(d.Channel)
+		if err != nil {
+			return "", "", err
+		}
+		var finder ThreadFinder
+		if d.Find != nil {
+			finder = *d.Find
+		}
+		if finder.Author != "" {
+			if finder.Author, err = dir.userID(finder.Author); err != nil {
+				return "", "", err
+			}
+		}
+		threadTS, err := discoverThread(p, dir.api, channelID, finder)
+		if err != nil {
+			return "", "", err
+		}
+		return channelID, threadTS, nil
+	default:
+		channelID, err := dir.channelID(d.Channel)
+		if err != nil {
+			return "", "", err
+		}
+		return channelID, d.Thread, nil
+	}
+}
+
+// destinationNames returns the configured destination names in a stable order
+func destinationNames(destinations map[string]Destination) []string {
+	names := make([]string, 0, len(destinations))
+	for name := range destinations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupDestination finds a destination by name for --to
+func lookupDestination(destinations map[string]Destination, name string) (Destination, error) {
+	dest, exists := destinations[name]
+	if !exists {
+		if len(destinations) == 0 {
+			return dest, fmt.Errorf("destination %q not found, none are set up in %s", name, settingsFile)
+		}
+		return dest, fmt.Errorf("destination %q not found (known: %s)", name, strings.Join(destinationNames(destinations), ", "))
+	}
+	return dest, nil
+}
+
+// chooseDestination shows a numbered menu of the configured destinations,
+// with the last one used as the default and the y/m/s/u choices of
+// promptDestination still accepted. It returns the name of the chosen
+// destination, which is empty when the user went for somewhere else.
+func chooseDestination(p *prompter, dir *directory, destinations map[string]Destination, last string) (string, string, string, error) {
+	names := destinationNames(destinations)
+	if _, exists := destinations[last]; !exists {
+		last = ""
+	}
+
+	p.printHeader("Destination 📍")
+	p.printInfo("Where do you want to post your standup?")
+	for i, name := range names {
+		line := fmt.Sprintf("%d. %s - %s", i+1, name, destinations[name].describe())
+		if name == last {
+			line += " (last used)"
+		}
+		p.printInfo(line)
+	}
+	p.printInfo("y. Reply to a thread in a channel")
+	p.printInfo("m. Message yourself directly")
+	p.printInfo("s. Post to Slackbot")
+	p.printInfo("u. Message any user by ID")
+	p.printInfo("o. Somewhere else")
+	if last != "" {
+		p.printInfo("Press Enter for " + last)
+	}
+
+	for {
+		answer, err := p.choose()
+		if err != nil {
+			return "", "", "", err
+		}
+
+		name := ""
+		if answer == "" && last != "" {
+			name = last
+		} else if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(names) {
+			name = names[n-1]
+		} else if _, exists := destinations[answer]; exists {
+			name = answer
+		} else if isDestinationShortcut(answer) {
+			channelID, threadTS, err := followDestinationChoice(p, dir, answer)
+			return "", channelID, threadTS, err
+		} else if strings.ToLower(answer) == "o" || strings.ToLower(answer) == "other" {
+			channelID, threadTS, err := promptDestination(p, dir)
+			return "", channelID, threadTS, err
+		}
+
+		if name == "" {
+			p.printError(fmt.Sprintf("Enter a number from 1 to %d, a destination name, y, m, s, u or o", len(names)))
+			continue
+		}
+
+		channelID, threadTS, err := resolveDestination(p, dir, destinations[name])
+		if err != nil {
+			return "", "", "", err
+		}
+		p.printSuccess(fmt.Sprintf("Posting to %s (%s)", name, destinations[name].describe()))
+		return name, channelID, threadTS, nil
+	}
+}
+
+// rememberDestination records where the standup went, to offer it first
+// next time: the named destination if one was used, otherwise the channel
+func rememberDestination(p *prompter, name, channelID string) {
+	state := loadState()
+	if name != "" {
+		state.LastDestination = name
+	}
+
+	key := channelID
+	if name != "" {
+		key = "to:" + name
+	}
+	if state.Usage == nil {
+		state.Usage = make(map[string]Usage)
+	}
+	usage := state.Usage[key]
+	usage.Count++
+	usage.Last = time.Now().Unix()
+	state.Usage[key] = usage
+
+	if err := saveState(state); err != nil {
+		p.printInfo(fmt.Sprintf("Warning: Could not remember the destination: %v", err))
+	}
+}
+
+// usageRank scores how likely something is to be posted to again: every
+// post counts, and a post loses half its weight per week since
+func usageRank(usage Usage, now time.Time) float64 {
+	if usage.Count == 0 {
+		return 0
+	}
+	weeks := now.Sub(time.Unix(usage.Last, 0)).Hours() / (24 * 7)
+	return float64(usage.Count) * math.Pow(0.5, weeks)
+}
+
+// pickDestination is the interactive counterpart of chooseDestination: one
+// type-to-filter list of the saved destinations, channels and DMs, with the
+// ones posted to most and most recently first
+func pickDestination(p *prompter, dir *directory, destinations map[string]Destination) (string, string, string, error) {
+	channels, dms, err := dir.conversations()
+	if err != nil {
+		return "", "", "", err
+	}
+
+	state := loadState()
+	now := time.Now()
+
+	// What each picker item stands for
+	type target struct {
+		destination string
+		channelID   string
+		isChannel   bool
+	}
+	var items []pickerItem
+	var targets []target
+
+	for _, name := range destinationNames(destinations) {
+		rank := usageRank(state.Usage["to:"+name], now)
+		if name == state.LastDestination {
+			rank += 1 // Ahead of anything used about as much
+		}
+		items = append(items, pickerItem{label: name, detail: destinations[name].describe(), rank: rank})
+		targets = append(targets, target{destination: name})
+	}
+	for _, channel := range channels {
+		if !channel.Member {
+			continue
+		}
+		items = append(items, pickerItem{label: "#" + channel.Name, detail: "channel", rank: usageRank(state.Usage[channel.ID], now)})
+		targets = append(targets, target{channelID: channel.ID, isChannel: true})
+	}
+	for _, dm := range dms {
+		detail := "direct message"
+		if strings.Contains(dm.Name, ",") {
+			detail = "group message"
+		}
+		items = append(items, pickerItem{label: dm.Name, detail: detail, rank: usageRank(state.Usage[dm.ID], now)})
+		targets = append(targets, target{channelID: dm.ID})
+	}
+	items = append(items, pickerItem{label: "Other…", detail: "a message link, Slackbot, yourself or a user", rank: -1})
+	targets = append(targets, target{})
+
+	p.printHeader("Destination 📍")
+	chosen, err := p.pick("Where do you want to post your standup?", items)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	switch t := targets[chosen]; {
+	case t.destination != "":
+		channelID, threadTS, err := resolveDestination(p, dir, destinations[t.destination])
+		return t.destination, channelID, threadTS, err
+	case t.isChannel:
+		threadTS, err := askThread(p, dir, t.channelID)
+		return "", t.channelID, threadTS, err
+	case t.channelID != "":
+		return "", t.channelID, "", nil
+	default:
+		channelID, threadTS, err := promptDestination(p, dir)
+		return "", channelID, threadTS, err
+	}
+}
+
+// askThread asks whether to reply in a thread of the chosen channel
+func askThread(p *prompter, dir *directory, channelID string) (string, error) {
+	for {
+		answer, err := p.getInput("Reply in a thread? Paste a message link, \"auto\" for today's standup thread, or press Enter for a new message")
+		if err != nil {
+			return "", err
+		}
+
+		switch {
+		case answer == "":
+			return "", nil
+		case answer == autoThread:
+			return discoverThread(p, dir.api, channelID, ThreadFinder{})
+		}
+
+		link, err := parseSlackLink(answer)
+		if err != nil {
+			p.printError(fmt.Sprintf("Parsing Slack link: %v", err))
+			continue
+		}
+		if link.ChannelID != channelID || link.ThreadTS == "" {
+			p.printError("That is not a message in the chosen channel")
+			continue
+		}
+		return link.ThreadTS, nil
+	}
+}
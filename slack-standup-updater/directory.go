@@ -0,0 +1,439 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	// directoryCacheFile keeps channel and user names per workspace
+	directoryCacheFile = "cache.json"
+
+	// directoryCacheTTL is how long looked up names are trusted; a name that
+	// is not in the cache is always looked up again
+	directoryCacheTTL = 24 * time.Hour
+)
+
+var (
+	channelIDRe = regexp.MustCompile(`^[CDG][A-Z0-9]{8,}$`)
+	userIDRe    = regexp.MustCompile(`^[UWB][A-Z0-9]{8,}$`)
+	emailRe     = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+)
+
+// directoryEntry is a channel or user that can be looked up by name
+type directoryEntry struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name,omitempty"`
+	RealName    string `json:"real_name,omitempty"`
+	Member      bool   `json:"member,omitempty"` // Channels the user is in
+}
+
+// label describes an entry when asking which of several was meant
+func (e directoryEntry) label() string {
+	names := []string{e.Name}
+	for _, name := range []string{e.DisplayName, e.RealName} {
+		if name != "" && name != e.Name {
+			names = append(names, name)
+		}
+	}
+	return fmt.Sprintf("%s (%s)", strings.Join(names, " / "), e.ID)
+}
+
+// teamDirectory is the cached part of one workspace's directory
+type teamDirectory struct {
+	Channels        []directoryEntry          `json:"channels,omitempty"`
+	ChannelsFetched int64                     `json:"channels_fetched,omitempty"`
+	Users           []directoryEntry          `json:"users,omitempty"`
+	UsersFetched    int64                     `json:"users_fetched,omitempty"`
+	Emails          map[string]directoryEmail `json:"emails,omitempty"`
+
+	// DMs are direct and group messages, named after the other members
+	DMs        []directoryEntry `json:"dms,omitempty"`
+	DMsFetched int64            `json:"dms_fetched,omitempty"`
+}
+
+// directoryEmail is the result of one users.lookupByEmail call
+type directoryEmail struct {
+	ID      string `json:"id"`
+	Fetched int64  `json:"fetched"`
+}
+
+// directory resolves channel names, handles and emails to IDs, keeping what
+// it learns in cache.json so most runs need no extra API calls
+type directory struct {
+	p      *prompter
+	api    *slack.Client
+	teamID string
+	cache  map[string]*teamDirectory // Keyed by team ID
+}
+
+// newDirectory returns a directory for the workspace the client is signed in to
+func newDirectory(p *prompter, api *slack.Client) *directory {
+	return &directory{p: p, api: api}
+}
+
+// channelID resolves "#name", "name" or an ID to a channel ID
+func (d *directory) channelID(ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if channelIDRe.MatchString(ref) {
+		return ref, nil
+	}
+	name := strings.ToLower(strings.TrimPrefix(ref, "#"))
+	if name == "" {
+		return "", fmt.Errorf("no channel given")
+	}
+
+	team, err := d.team()
+	if err != nil {
+		return "", err
+	}
+
+	fresh := time.Since(time.Unix(team.ChannelsFetched, 0)) < directoryCacheTTL
+	matches := matchChannels(team.Channels, name)
+	if len(matches) == 0 || !fresh {
+		// The cache may predate the channel, so look again before giving up
+		if err := d.fetchChannels(team); err != nil {
+			return "", err
+		}
+		matches = matchChannels(team.Channels, name)
+	}
+
+	entry, err := d.pick(matches, "channel", "#"+name)
+	if err != nil {
+		return "", err
+	}
+	return entry.ID, nil
+}
+
+// userID resolves "@handle", a display or real name, an email address or an
+// ID to a user ID
+func (d *directory) userID(ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if userIDRe.MatchString(ref) {
+		return ref, nil
+	}
+	if emailRe.MatchString(ref) {
+		return d.userByEmail(strings.ToLower(ref))
+	}
+	name := strings.ToLower(strings.TrimPrefix(ref, "@"))
+	if name == "" {
+		return "", fmt.Errorf("no user given")
+	}
+
+	team, err := d.team()
+	if err != nil {
+		return "", err
+	}
+
+	fresh := time.Since(time.Unix(team.UsersFetched, 0)) < directoryCacheTTL
+	matches := matchUsers(team.Users, name)
+	if len(matches) == 0 || !fresh {
+		if err := d.fetchUsers(team); err != nil {
+			return "", err
+		}
+		matches = matchUsers(team.Users, name)
+	}
+
+	entry, err := d.pick(matches, "user", "@"+name)
+	if err != nil {
+		return "", err
+	}
+	return entry.ID, nil
+}
+
+// userByEmail looks a user up with users.lookupByEmail
+func (d *directory) userByEmail(email string) (string, error) {
+	team, err := d.team()
+	if err != nil {
+		return "", err
+	}
+	if cached, ok := team.Emails[email]; ok && time.Since(time.Unix(cached.Fetched, 0)) < directoryCacheTTL {
+		return cached.ID, nil
+	}
+
+	user, err := d.api.GetUserByEmail(email)
+	if err != nil {
+		if strings.Contains(err.Error(), "users_not_found") {
+			return "", fmt.Errorf("no user with email %s", email)
+		}
+		return "", fmt.Errorf("looking up %s: %v%s", email, err, scopeHint(err, "users:read.email"))
+	}
+
+	if team.Emails == nil {
+		team.Emails = make(map[string]directoryEmail)
+	}
+	team.Emails[email] = directoryEmail{ID: user.ID, Fetched: time.Now().Unix()}
+	d.save()
+	return user.ID, nil
+}
+
+// pick returns the one match, or asks which one was meant
+func (d *directory) pick(matches []directoryEntry, kind, ref string) (directoryEntry, error) {
+	switch len(matches) {
+	case 0:
+		return directoryEntry{}, fmt.Errorf("no %s named %s", kind, ref)
+	case 1:
+		return matches[0], nil
+	}
+
+	d.p.printInfo(fmt.Sprintf("Several %ss match %s:", kind, ref))
+	for i, entry := range matches {
+		d.p.printInfo(fmt.Sprintf("%d. %s", i+1, entry.label()))
+	}
+	for {
+		answer, err := d.p.choose()
+		if err != nil {
+			return directoryEntry{}, err
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(matches) {
+			return matches[n-1], nil
+		}
+		d.p.printError(fmt.Sprintf("Enter a number from 1 to %d", len(matches)))
+	}
+}
+
+// matchChannels finds channels by name
+func matchChannels(channels []directoryEntry, name string) []directoryEntry {
+	var matches []directoryEntry
+	for _, channel := range channels {
+		if channel.Name == name {
+			matches = append(matches, channel)
+		}
+	}
+	return matches
+}
+
+// matchUsers finds users by handle, or failing that by display or real
+// name, ignoring case
+func matchUsers(users []directoryEntry, name string) []directoryEntry {
+	var handles, names []directoryEntry
+	for _, user := range users {
+		switch {
+		case strings.ToLower(user.Name) == name:
+			handles = append(handles, user)
+		case strings.ToLower(user.DisplayName) == name || strings.ToLower(user.RealName) == name:
+			names = append(names, user)
+		}
+	}
+	if len(handles) > 0 {
+		return handles
+	}
+	return names
+}
+
+// fetchChannels lists every channel the user can see, page by page
+func (d *directory) fetchChannels(team *teamDirectory) error {
+	d.p.printInfo("Looking up channels... 🔍")
+
+	var channels []directoryEntry
+	params := &slack.GetConversationsParameters{
+		Types:           []string{"public_channel", "private_channel"},
+		ExcludeArchived: true,
+		Limit:           1000,
+	}
+	for {
+		page, cursor, err := d.api.GetConversations(params)
+		if err != nil {
+			return fmt.Errorf("listing channels: %v%s", err, scopeHint(err, "channels:read and groups:read"))
+		}
+		for _, channel := range page {
+			channels = append(channels, directoryEntry{ID: channel.ID, Name: strings.ToLower(channel.Name), Member: channel.IsMember})
+		}
+		if cursor == "" {
+			break
+		}
+		params.Cursor = cursor
+	}
+
+	team.Channels = channels
+	team.ChannelsFetched = time.Now().Unix()
+	d.save()
+	return nil
+}
+
+// fetchUsers lists every active user of the workspace; the client follows
+// the pagination and waits out rate limits
+func (d *directory) fetchUsers(team *teamDirectory) error {
+	d.p.printInfo("Looking up users... 🔍")
+
+	users, err := d.api.GetUsers(slack.GetUsersOptionLimit(1000))
+	if err != nil {
+		return fmt.Errorf("listing users: %v%s", err, scopeHint(err, "users:read"))
+	}
+
+	entries := make([]directoryEntry, 0, len(users))
+	for _, user := range users {
+		if user.Deleted {
+			continue
+		}
+		entries = append(entries, directoryEntry{
+			ID:          user.ID,
+			Name:        user.Name,
+			DisplayName: user.Profile.DisplayName,
+			RealName:    user.RealName,
+		})
+	}
+
+	team.Users = entries
+	team.UsersFetched = time.Now().Unix()
+	d.save()
+	return nil
+}
+
+// conversations returns the channels and DMs the user can post to, for the
+// picker. DMs are left out, with a warning, when they cannot be listed.
+func (d *directory) conversations() ([]directoryEntry, []directoryEntry, error) {
+	team, err := d.team()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if time.Since(time.Unix(team.ChannelsFetched, 0)) >= directoryCacheTTL {
+		if err := d.fetchChannels(team); err != nil {
+			return nil, nil, err
+		}
+	}
+	if time.Since(time.Unix(team.DMsFetched, 0)) >= directoryCacheTTL {
+		if err := d.fetchDMs(team); err != nil {
+			d.p.printInfo(fmt.Sprintf("Warning: Direct messages are not listed: %v", err))
+		}
+	}
+	return team.Channels, team.DMs, nil
+}
+
+// fetchDMs lists the user's direct and group messages, page by page, and
+// names each after its other members
+func (d *directory) fetchDMs(team *teamDirectory) error {
+	if time.Since(time.Unix(team.UsersFetched, 0)) >= directoryCacheTTL {
+		if err := d.fetchUsers(team); err != nil {
+			return err
+		}
+	}
+	handles := make(map[string]string, len(team.Users))
+	for _, user := range team.Users {
+		handles[user.ID] = user.Name
+	}
+
+	d.p.printInfo("Looking up direct messages... 🔍")
+
+	var dms []directoryEntry
+	params := &slack.GetConversationsParameters{
+		Types:           []string{"im", "mpim"},
+		ExcludeArchived: true,
+		Limit:           1000,
+	}
+	for {
+		page, cursor, err := d.api.GetConversations(params)
+		if err != nil {
+			return fmt.Errorf("listing direct messages: %v%s", err, scopeHint(err, "im:read and mpim:read"))
+		}
+		for _, channel := range page {
+			if channel.IsIM {
+				handle, ok := handles[channel.User]
+				if !ok {
+					// Deleted users and bots other than Slackbot
+					if channel.User != slackbotUserID {
+						continue
+					}
+					handle = "slackbot"
+				}
+				dms = append(dms, directoryEntry{ID: channel.ID, Name: "@" + handle})
+				continue
+			}
+			dms = append(dms, directoryEntry{ID: channel.ID, Name: groupDMName(channel.Name)})
+		}
+		if cursor == "" {
+			break
+		}
+		params.Cursor = cursor
+	}
+
+	team.DMs = dms
+	team.DMsFetched = time.Now().Unix()
+	d.save()
+	return nil
+}
+
+// groupDMName turns "mpdm-alice--bob--carol-1" into "@alice, @bob, @carol"
+func groupDMName(name string) string {
+	trimmed := strings.TrimPrefix(name, "mpdm-")
+	if i := strings.LastIndex(trimmed, "-"); i > 0 {
+		trimmed = trimmed[:i]
+	}
+	members := strings.Split(trimmed, "--")
+	for i, member := range members {
+		members[i] = "@" + member
+	}
+	return strings.Join(members, ", ")
+}
+
+// team returns the cached directory of the signed in workspace, loading
+// the cache on first use
+func (d *directory) team() (*teamDirectory, error) {
+	if d.teamID == "" {
+		info, err := identity(d.api)
+		if err != nil {
+			return nil, fmt.Errorf("getting workspace info: %v", err)
+		}
+		d.teamID = info.TeamID
+	}
+
+	if d.cache == nil {
+		d.cache = loadDirectoryCache()
+	}
+	team, ok := d.cache[d.teamID]
+	if !ok {
+		team = &teamDirectory{}
+		d.cache[d.teamID] = team
+	}
+	return team, nil
+}
+
+// loadDirectoryCache reads cache.json; a missing or damaged cache is empty
+func loadDirectoryCache() map[string]*teamDirectory {
+	cache := make(map[string]*teamDirectory)
+
+	path, err := configPath(directoryCacheFile)
+	if err != nil {
+		return cache
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return make(map[string]*teamDirectory)
+	}
+	return cache
+}
+
+// save writes the cache back; failing to do so only costs a lookup next time
+func (d *directory) save() {
+	path, err := configPath(directoryCacheFile)
+	if err != nil {
+		return
+	}
+	data, err := json.Marshal(d.cache)
+	if err != nil {
+		return
+	}
+	if err := writeFileAtomic(path, data, 0600); err != nil {
+		d.p.printInfo(fmt.Sprintf("Warning: Could not save the lookup cache: %v", err))
+	}
+}
+
+// scopeHint explains a missing_scope error
+func scopeHint(err error, scopes string) string {
+	if strings.Contains(err.Error(), "missing_scope") {
+		return fmt.Sprintf(" (run `standup login` again to grant %s)", scopes)
+	}
+	return ""
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// editorHelp opens every document written for the editor
+const editorHelp = `<!--
+  Write your standup below. Every "## " heading is one question: put each
+  bullet point on its own line under it; indent a line to continue the
+  bullet point above. Items from your last standup start with [ ]; mark
+  them [x] done, [~] partly done, [-] dropped or [>] carried over. Comments
+  like this one are ignored.
+  Save and close the editor when done. Leaving the file unchanged or empty
+  cancels the standup.
+-->
+`
+
+var (
+	editorCommentRe = regexp.MustCompile(`(?s)<!--.*?-->`)
+	editorIDRe      = regexp.MustCompile(`<!--\s*id:\s*(\S+)\s*-->`)
+)
+
+// editorTemplate renders the questions as a markdown document, one heading
+// per question with its current answer underneath
+func editorTemplate(questions []Question, prefill map[string]string) string {
+	var builder strings.Builder
+	builder.WriteString(editorHelp)
+
+	for _, q := range questions {
+		heading := q.Prompt
+		if q.Required {
+			heading += " (required)"
+		}
+		fmt.Fprintf(&builder, "\n## %s\n<!-- id: %s -->\n", heading, q.ID)
+		for _, line := range strings.Split(prefill[q.ID], "\n") {
+			if strings.TrimSpace(line) != "" {
+				builder.WriteString(strings.TrimRight(line, " \t") + "\n")
+			}
+		}
+	}
+	return builder.String()
+}
+
+// parseEditorDocument reads the answers back from an edited document. The
+// id comment under each heading ties it to its question, so headings may be
+// reworded; without it the heading has to match the prompt.
+func parseEditorDocument(questions []Question, doc string) (map[string]string, error) {
+	answers := make(map[string]string)
+
+	for _, section := range splitSections(doc) {
+		if section.heading == "" {
+			if strings.TrimSpace(editorCommentRe.ReplaceAllString(section.body, "")) != "" {
+				return nil, fmt.Errorf("text before the first heading; answers go under a \"## \" question heading")
+			}
+			continue
+		}
+
+		q, err := sectionQuestion(questions, section)
+		if err != nil {
+			return nil, err
+		}
+		if _, seen := answers[q.ID]; seen {
+			return nil, fmt.Errorf("question %q appears more than once", q.ID)
+		}
+
+		var lines []string
+		for _, line := range strings.Split(editorCommentRe.ReplaceAllString(section.body, ""), "\n") {
+			// Indented lines continue the bullet point above, so keep their indent
+			if strings.TrimSpace(line) != "" {
+				lines = append(lines, strings.TrimRight(line, " \t"))
+			}
+		}
+		answers[q.ID] = strings.Join(lines, "\n")
+	}
+
+	for _, q := range questions {
+		if answers[q.ID] == "" && q.Required {
+			return answers, fmt.Errorf("%q needs an answer", q.Prompt)
+		}
+	}
+	return answers, nil
+}
+
+// editorSection is a "## " heading and the text up to the next one
+type editorSection struct {
+	heading string
+	body    string
+}
+
+// splitSections cuts a document at its "## " headings; text before the
+// first heading comes back with an empty heading
+func splitSections(doc string) []editorSection {
+	sections := []editorSection{{}}
+	inComment := false
+	for _, line := range strings.Split(doc, "\n") {
+		// A heading inside the help comment is not a heading
+		if !inComment && strings.HasPrefix(line, "## ") {
+			sections = append(sections, editorSection{heading: strings.TrimSpace(line[3:])})
+			continue
+		}
+		last := &sections[len(sections)-1]
+		last.body += line + "\n"
+
+		if strings.Contains(line, "<!--") {
+			inComment = true
+		}
+		if strings.Contains(line, "-->") {
+			inComment = false
+		}
+	}
+	return sections
+}
+
+// sectionQuestion finds the question a section answers
+func sectionQuestion(questions []Question, section editorSection) (Question, error) {
+	if match := editorIDRe.FindStringSubmatch(section.body); match != nil {
+		for _, q := range questions {
+			if q.ID == match[1] {
+				return q, nil
+			}
+		}
+		return Question{}, fmt.Errorf("no question with id %q", match[1])
+	}
+
+	heading := strings.TrimSpace(strings.TrimSuffix(section.heading, "(required)"))
+	for _, q := range questions {
+		if strings.EqualFold(heading, q.Prompt) || strings.EqualFold(heading, q.heading()) || strings.EqualFold(heading, q.ID) {
+			return q, nil
+		}
+	}
+	return Question{}, fmt.Errorf("heading %q does not match any question", section.heading)
+}
+
+// composeInEditor writes the standup as a markdown document, opens it in
+// the user's editor and reads the answers back. A document with mistakes is
+// offered for editing again, as it was left.
+func composeInEditor(p *prompter, questions []Question, prefill map[string]string) (map[string]string, error) {
+	file, err := os.CreateTemp("", "standup-*.md")
+	if err != nil {
+		return nil, fmt.Errorf("creating the standup document: %v", err)
+	}
+	path := file.Name()
+	defer os.Remove(path)
+
+	template := editorTemplate(questions, prefill)
+	_, err = file.WriteString(template)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("writing the standup document: %v", err)
+	}
+
+	for {
+		p.printInfo("Opening the standup in your editor... ✍️")
+		if err := openEditor(path); err != nil {
+			return nil, err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading the standup document: %v", err)
+		}
+		doc := string(data)
+
+		if doc == template {
+			return nil, fmt.Errorf("the standup was left unchanged, nothing was posted")
+		}
+		if strings.TrimSpace(editorCommentRe.ReplaceAllString(doc, "")) == "" {
+			return nil, fmt.Errorf("the standup is empty, nothing was posted")
+		}
+
+		answers, err := parseEditorDocument(questions, doc)
+		if err == nil {
+			return answers, nil
+		}
+
+		p.printError(err.Error())
+		p.printInfo("Open the editor again to fix it? (y/n)")
+		answer, chooseErr := p.choose()
+		if chooseErr != nil {
+			return nil, chooseErr
+		}
+		if strings.ToLower(answer) != "y" && strings.ToLower(answer) != "yes" {
+			return nil, err
+		}
+	}
+}
+
+// editorCommand returns the user's editor: $VISUAL, $EDITOR or a platform default
+func editorCommand() string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.TrimSpace(os.Getenv(name)); editor != "" {
+			return editor
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
+
+// openEditor edits a file and waits for the editor to exit. The command goes
+// through the shell, so editors with arguments such as "code --wait" work.
+func openEditor(path string) error {
+	editor := editorCommand()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", editor+` "`+path+`"`)
+	} else {
+		cmd = exec.Command("sh", "-c", editor+` "$1"`, "sh", path)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("running editor %q: %v", editor, err)
+	}
+	return nil
+}
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	storeFormatVersion = 1
+
+	// storeKDF is memory-hard, so guessing passphrases on GPUs stays costly.
+	// Its cost follows the second recommendation of RFC 9106 and is saved with
+	// every file, so it can be raised without breaking old ones.
+	storeKDF        = "argon2id"
+	storeKDFTime    = 3
+	storeKDFMemory  = 64 * 1024 // KiB
+	storeKDFThreads = 4
+
+	// legacyStoreKDF is what the first encrypted files used; they can still be
+	// opened and are upgraded once the passphrase is typed
+	legacyStoreKDF = "pbkdf2-sha256"
+
+	// storeAAD binds the ciphertext to this use, so it cannot be swapped for
+	// another blob sealed with the same key
+	storeAAD = "slack-standup-updater token store v1"
+
+	unlockCacheFile           = "unlock.json"
+	defaultUnlockCacheMinutes = 15
+	maxPassphraseAttempts     = 3
+)
+
+// encryptedStore is the on-disk form of an encrypted token.json
+type encryptedStore struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`        // Time cost for argon2id
+	Memory     uint32 `json:"memory,omitempty"`  // KiB, argon2id only
+	Threads    uint8  `json:"threads,omitempty"` // argon2id only
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// storeEnvelope tells an encrypted token.json apart from a plaintext one
+type storeEnvelope struct {
+	Encrypted *encryptedStore `json:"encrypted,omitempty"`
+}
+
+// storeKey is a key derived from the passphrase, along with the KDF, salt
+// and cost it was derived with
+type storeKey struct {
+	key        []byte
+	kdf        string
+	salt       []byte
+	iterations int
+	memory     uint32
+	threads    uint8
+}
+
+// newKDFParams returns the KDF settings for a new key with a fresh salt
+func newKDFParams() (storeKey, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return storeKey{}, err
+	}
+	return storeKey{kdf: storeKDF, salt: salt, iterations: storeKDFTime, memory: storeKDFMemory, threads: storeKDFThreads}, nil
+}
+
+// kdfParams returns the KDF settings a store was sealed with
+func kdfParams(enc *encryptedStore) storeKey {
+	return storeKey{kdf: enc.KDF, salt: enc.Salt, iterations: enc.Iterations, memory: enc.Memory, threads: enc.Threads}
+}
+
+// deriveStoreKey stretches a passphrase into an AES-256 key with the KDF
+// and cost in params
+func deriveStoreKey(passphrase string, params storeKey) (storeKey, error) {
+	switch params.kdf {
+	case storeKDF:
+		if params.iterations < 1 || params.memory < 8*uint32(params.threads) || params.threads < 1 {
+			return storeKey{}, fmt.Errorf("invalid argon2id parameters in token store")
+		}
+		params.key = argon2.IDKey([]byte(passphrase), params.salt, uint32(params.iterations), params.memory, params.threads, 32)
+	case legacyStoreKDF:
+		key, err := pbkdf2.Key(sha256.New, passphrase, params.salt, params.iterations, 32)
+		if err != nil {
+			return storeKey{}, err
+		}
+		params.key = key
+	default:
+		return storeKey{}, fmt.Errorf("unsupported key derivation %q", params.kdf)
+	}
+	return params, nil
+}
+
+// sealStore encrypts the plaintext token store with AES-256-GCM
+func sealStore(key storeKey, plaintext []byte) (*encryptedStore, error) {
+	block, err := aes.NewCipher(key.key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return &encryptedStore{
+		Version:    storeFormatVersion,
+		KDF:        key.kdf,
+		Iterations: key.iterations,
+		Memory:     key.memory,
+		Threads:    key.threads,
+		Salt:       key.salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, []byte(storeAAD)),
+	}, nil
+}
+
+// openStore decrypts an encrypted token store; a wrong key fails
+// authentication rather than producing garbage
+func openStore(key storeKey, enc *encryptedStore) ([]byte, error) {
+	block, err := aes.NewCipher(key.key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(enc.Nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("corrupt token store: bad nonce")
+	}
+
+	plaintext, err := gcm.Open(nil, enc.Nonce, enc.Ciphertext, []byte(storeAAD))
+	if err != nil {
+		return nil, errWrongPassphrase
+	}
+	return plaintext, nil
+}
+
+var errWrongPassphrase = errors.New("wrong passphrase or corrupt token store")
+
+// sessionKey keeps the unlocked key for the rest of this run, so a command
+// that reads token.json more than once only asks for the passphrase once
+var sessionKey *storeKey
+
+// unlockStore decrypts token.json, using the unlock cache when it is still
+// fresh and otherwise asking for the passphrase. A store sealed with the
+// legacy KDF comes back with a new argon2id key once the passphrase is
+// known, so saving it again upgrades it.
+func unlockStore(p *prompter, enc *encryptedStore) ([]byte, storeKey, error) {
+	if enc.Version != storeFormatVersion || (enc.KDF != storeKDF && enc.KDF != legacyStoreKDF) {
+		return nil, storeKey{}, fmt.Errorf("unsupported token store format %d/%s", enc.Version, enc.KDF)
+	}
+
+	if sessionKey != nil && subtle.ConstantTimeCompare(sessionKey.salt, enc.Salt) == 1 {
+		if plaintext, err := openStore(*sessionKey, enc); err == nil {
+			return plaintext, *sessionKey, nil
+		}
+	}
+
+	if key, ok := readUnlockCache(enc); ok {
+		if plaintext, err := openStore(key, enc); err == nil {
+			return plaintext, key, nil
+		}
+		clearUnlockCache()
+	}
+
+	// A passphrase from the environment is for unattended runs, e.g. cron
+	if passphrase, ok := os.LookupEnv("STANDUP_PASSPHRASE"); ok {
+		key, err := deriveStoreKey(passphrase, kdfParams(enc))
+		if err != nil {
+			return nil, storeKey{}, err
+		}
+		plaintext, err := openStore(key, enc)
+		if err != nil {
+			return nil, storeKey{}, fmt.Errorf("STANDUP_PASSPHRASE: %v", err)
+		}
+		if key, err = upgradeStoreKey(passphrase, key); err != nil {
+			return nil, storeKey{}, err
+		}
+		return plaintext, key, nil
+	}
+
+	for attempt := 1; ; attempt++ {
+		passphrase, err := p.getSecret("Enter the passphrase for your saved Slack credentials")
+		if err != nil {
+			return nil, storeKey{}, err
+		}
+
+		key, err := deriveStoreKey(passphrase, kdfParams(enc))
+		if err != nil {
+			return nil, storeKey{}, err
+		}
+
+		plaintext, err := openStore(key, enc)
+		if err == nil {
+			if key, err = upgradeStoreKey(passphrase, key); err != nil {
+				return nil, storeKey{}, err
+			}
+			sessionKey = &key
+			writeUnlockCache(p, key)
+			return plaintext, key, nil
+		}
+		if attempt == maxPassphraseAttempts {
+			return nil, storeKey{}, err
+		}
+		p.printError("Wrong passphrase, try again.")
+	}
+}
+
+// upgradeStoreKey replaces a key derived with the legacy KDF by an argon2id
+// key for the same passphrase; current keys are returned as they are
+func upgradeStoreKey(passphrase string, key storeKey) (storeKey, error) {
+	if key.kdf == storeKDF {
+		return key, nil
+	}
+	params, err := newKDFParams()
+	if err != nil {
+		return storeKey{}, err
+	}
+	return deriveStoreKey(passphrase, params)
+}
+
+// newStoreKey asks for a new passphrase (twice) and derives a key for it
+// with a fresh salt
+func newStoreKey(p *prompter) (storeKey, error) {
+	passphrase, ok := os.LookupEnv("STANDUP_PASSPHRASE")
+	if !ok {
+		p.printInfo("Your Slack credentials will be encrypted with a passphrase 🔐")
+		for {
+			first, err := p.getSecret("Choose a passphrase")
+			if err != nil {
+				return storeKey{}, err
+			}
+			if first == "" {
+				p.printError("The passphrase cannot be empty.")
+				continue
+			}
+			second, err := p.getSecret("Repeat the passphrase")
+			if err != nil {
+				return storeKey{}, err
+			}
+			if subtle.ConstantTimeCompare([]byte(first), []byte(second)) == 1 {
+				passphrase = first
+				break
+			}
+			p.printError("The passphrases do not match, try again.")
+		}
+	}
+	if passphrase == "" {
+		return storeKey{}, fmt.Errorf("the passphrase cannot be empty")
+	}
+
+	params, err := newKDFParams()
+	if err != nil {
+		return storeKey{}, err
+	}
+	key, err := deriveStoreKey(passphrase, params)
+	if err != nil {
+		return storeKey{}, err
+	}
+	sessionKey = &key
+	writeUnlockCache(p, key)
+	return key, nil
+}
+
+// unlockCache is a derived key kept for a short while so the passphrase is
+// not needed on every run
+type unlockCache struct {
+	Key     []byte `json:"key"`
+	Salt    []byte `json:"salt"`
+	Expires int64  `json:"expires"`
+}
+
+// unlockCachePath keeps the cache out of the (possibly backed up) config
+// directory, in the per-user runtime directory where one exists
+func unlockCachePath() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "slack-standup-updater-"+strconv.Itoa(os.Getuid()), unlockCacheFile)
+}
+
+// unlockCacheDuration is how long an unlocked key is kept, 0 to never cache
+func unlockCacheDuration() time.Duration {
+	settings, err := loadSettings()
+	if err != nil || settings.UnlockCacheMinutes == nil {
+		return defaultUnlockCacheMinutes * time.Minute
+	}
+	return time.Duration(*settings.UnlockCacheMinutes) * time.Minute
+}
+
+// readUnlockCache returns the cached key for this store if it has not expired.
+// A cache that has expired, is corrupt or belongs to another store is
+// removed, since it still holds a key.
+func readUnlockCache(enc *encryptedStore) (storeKey, bool) {
+	data, err := os.ReadFile(unlockCachePath())
+	if err != nil {
+		return storeKey{}, false
+	}
+
+	var cache unlockCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		clearUnlockCache()
+		return storeKey{}, false
+	}
+	if time.Now().Unix() >= cache.Expires || subtle.ConstantTimeCompare(cache.Salt, enc.Salt) != 1 {
+		clearUnlockCache()
+		return storeKey{}, false
+	}
+
+	key := kdfParams(enc)
+	key.key = cache.Key
+	return key, true
+}
+
+// writeUnlockCache remembers a key until the cache duration runs out
+func writeUnlockCache(p *prompter, key storeKey) {
+	duration := unlockCacheDuration()
+	if duration <= 0 {
+		return
+	}
+
+	data, err := json.Marshal(unlockCache{
+		Key:     key.key,
+		Salt:    key.salt,
+		Expires: time.Now().Add(duration).Unix(),
+	})
+	if err != nil {
+		return
+	}
+
+	path := unlockCachePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		p.printInfo(fmt.Sprintf("Warning: Could not cache the unlocked credentials: %v", err))
+		return
+	}
+	// The shared temp directory fallback could hold a directory planted by
+	// someone else; only use one that is private to us
+	if info, err := os.Lstat(filepath.Dir(path)); err != nil || !info.IsDir() || info.Mode().Perm()&0077 != 0 {
+		p.printInfo("Warning: Not caching the unlocked credentials, the cache directory is not private")
+		return
+	}
+	if err := writeFileAtomic(path, data, 0600); err != nil {
+		p.printInfo(fmt.Sprintf("Warning: Could not cache the unlocked credentials: %v", err))
+	}
+}
+
+// clearUnlockCache forgets the cached key, so the next run asks again
+func clearUnlockCache() error {
+	err := os.Remove(unlockCachePath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// runLock implements `standup lock`
+func runLock(p *prompter, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("lock takes no arguments")
+	}
+	if err := clearUnlockCache(); err != nil {
+		return err
+	}
+	p.printSuccess("Credentials locked, the passphrase will be asked for on the next run")
+	return nil
+}
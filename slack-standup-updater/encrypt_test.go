@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadUnlockCacheRemovesStaleCache(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	enc := &encryptedStore{KDF: storeKDF, Salt: salt, Iterations: storeKDFTime, Memory: storeKDFMemory, Threads: storeKDFThreads}
+
+	tests := []struct {
+		name string
+		data func() []byte
+	}{
+		{
+			name: "expired",
+			data: func() []byte {
+				data, _ := json.Marshal(unlockCache{Key: make([]byte, 32), Salt: salt, Expires: time.Now().Add(-time.Minute).Unix()})
+				return data
+			},
+		},
+		{
+			name: "other store",
+			data: func() []byte {
+				data, _ := json.Marshal(unlockCache{Key: make([]byte, 32), Salt: []byte("fedcba9876543210"), Expires: time.Now().Add(time.Hour).Unix()})
+				return data
+			},
+		},
+		{
+			name: "corrupt",
+			data: func() []byte { return []byte("{not json") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+			path := unlockCachePath()
+			if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, tt.data(), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, ok := readUnlockCache(enc); ok {
+				t.Fatalf("readUnlockCache() ok = true, want false")
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("cache file still exists after the read (stat err = %v)", err)
+			}
+		})
+	}
+}
+
+func TestReadUnlockCacheKeepsFreshCache(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	salt := []byte("0123456789abcdef")
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	path := unlockCachePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(unlockCache{Key: key, Salt: salt, Expires: time.Now().Add(time.Hour).Unix()})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok := readUnlockCache(&encryptedStore{KDF: storeKDF, Salt: salt, Iterations: storeKDFTime, Memory: storeKDFMemory, Threads: storeKDFThreads})
+	if !ok {
+		t.Fatalf("readUnlockCache() ok = false, want true")
+	}
+	if string(got.key) != string(key) {
+		t.Errorf("readUnlockCache() key = %x, want %x", got.key, key)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("fresh cache file was removed: %v", err)
+	}
+}
+
+func TestUpgradeStoreKeyFromLegacyKDF(t *testing.T) {
+	legacy, err := deriveStoreKey("hunter2", storeKey{kdf: legacyStoreKDF, salt: []byte("0123456789abcdef"), iterations: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := sealStore(legacy, []byte("tokens"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sealed.KDF != legacyStoreKDF {
+		t.Fatalf("sealStore() KDF = %q, want %q", sealed.KDF, legacyStoreKDF)
+	}
+
+	upgraded, err := upgradeStoreKey("hunter2", legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upgraded.kdf != storeKDF || string(upgraded.salt) == string(legacy.salt) {
+		t.Fatalf("upgradeStoreKey() kdf = %q, salt = %x, want %q with a new salt", upgraded.kdf, upgraded.salt, storeKDF)
+	}
+
+	resealed, err := sealStore(upgraded, []byte("tokens"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := deriveStoreKey("hunter2", kdfParams(resealed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plaintext, err := openStore(again, resealed); err != nil || string(plaintext) != "tokens" {
+		t.Errorf("openStore() after the upgrade = %q, %v, want %q, nil", plaintext, err, "tokens")
+	}
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// gitScanDepth is how deep below a root directory repositories are looked for
+const gitScanDepth = 4
+
+// gitLookback caps how far back commits are read when the last standup is old
+const gitLookback = 7 * 24 * time.Hour
+
+// GitSettings points the git providers at local repositories
+type GitSettings struct {
+	Repos  []string `json:"repos,omitempty"`  // Repositories to scan
+	Roots  []string `json:"roots,omitempty"`  // Every repository below these is scanned
+	Emails []string `json:"emails,omitempty"` // Commit authors; default: each repo's user.email
+}
+
+// configured reports whether any repositories are set up
+func (g GitSettings) configured() bool {
+	return len(g.Repos) > 0 || len(g.Roots) > 0
+}
+
+// defaultBranches are the long-lived branches feature branches start from
+var defaultBranches = map[string]bool{"main": true, "master": true, "trunk": true, "develop": true}
+
+// commitGroup is the commits of one branch of one repository, oldest first
+type commitGroup struct {
+	repo     string
+	branch   string
+	subjects []string
+}
+
+// bullet sums up a group as one bullet point; the default branch is left
+// out of the name
+func (g commitGroup) bullet() string {
+	name := filepath.Base(g.repo)
+	if !defaultBranches[g.branch] {
+		name += " (" + g.branch + ")"
+	}
+	return name + ": " + strings.Join(g.subjects, "; ")
+}
+
+// gitCommitSuggestions returns a bullet point per repository and branch with
+// the commits authored since the profile's previous standup
+func gitCommitSuggestions(git GitSettings, profile string, now time.Time) ([]string, error) {
+	repos, err := findRepos(git)
+	if err != nil {
+		return nil, err
+	}
+	since := commitsSince(profile, now)
+
+	var bullets []string
+	for _, repo := range repos {
+		groups, err := repoCommits(repo, git.Emails, since)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", repo, err)
+		}
+		for _, group := range groups {
+			bullets = append(bullets, group.bullet())
+		}
+	}
+	return bullets, nil
+}
+
+// commitsSince is when the profile's previous standup was posted, or the
+// start of the previous working day when there is no recent one, so on
+// Monday commits from Friday on are shown. Standups posted today, such as a
+// fix-up, do not count as the previous one.
+func commitsSince(profile string, now time.Time) time.Time {
+	today := startOfDay(now)
+	if entries, _, err := loadHistory(); err == nil {
+		for i := len(entries) - 1; i >= 0; i-- {
+			posted := time.Unix(entries[i].Posted, 0)
+			if entries[i].Profile != profile || !posted.Before(today) {
+				continue
+			}
+			if now.Sub(posted) < gitLookback {
+				return posted
+			}
+			break
+		}
+	}
+	return previousWorkday(now)
+}
+
+// previousWorkday returns the start of the last weekday before now
+func previousWorkday(now time.Time) time.Time {
+	day := startOfDay(now).AddDate(0, 0, -1)
+	for day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day
+}
+
+// findRepos lists the configured repositories and those found below the
+// configured roots, each once: linked worktrees share their branches with
+// the main one, so only the first worktree of a repository is kept
+func findRepos(git GitSettings) ([]string, error) {
+	seen := make(map[string]bool)
+	var repos []string
+	add := func(path string) {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return
+		}
+		key := abs
+		if common, err := runGit(abs, "rev-parse", "--path-format=absolute", "--git-common-dir"); err == nil {
+			key = strings.TrimSpace(common)
+		}
+		if !seen[key] {
+			seen[key] = true
+			repos = append(repos, abs)
+		}
+	}
+
+	for _, repo := range git.Repos {
+		path, err := expandHome(repo)
+		if err != nil {
+			return nil, err
+		}
+		if !isGitRepo(path) {
+			return nil, fmt.Errorf("%s is not a git repository", repo)
+		}
+		add(path)
+	}
+
+	for _, root := range git.Roots {
+		path, err := expandHome(root)
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Clean(path)
+		depth := strings.Count(path, string(filepath.Separator))
+		err = filepath.WalkDir(path, func(dir string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				// Unreadable directories are skipped, not fatal
+				if dir == path {
+					return err
+				}
+				return fs.SkipDir
+			}
+			if !entry.IsDir() {
+				return nil
+			}
+			if dir != path && (strings.HasPrefix(entry.Name(), ".") || entry.Name() == "node_modules" || entry.Name() == "vendor") {
+				return fs.SkipDir
+			}
+			if isGitRepo(dir) {
+				add(dir)
+				return fs.SkipDir
+			}
+			if strings.Count(dir, string(filepath.Separator))-depth >= gitScanDepth {
+				return fs.SkipDir
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("scanning %s: %v", root, err)
+		}
+	}
+	return repos, nil
+}
+
+// isGitRepo reports whether dir is the top of a repository or worktree
+func isGitRepo(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+// expandHome replaces a leading "~" with the home directory
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+// runGit runs a git command in a repository and returns its output
+func runGit(repo string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], message)
+		}
+		return "", fmt.Errorf("git %s: %v", args[0], err)
+	}
+	return string(out), nil
+}
+
+// localBranches lists a repository's feature branches and default branches
+func localBranches(repo string) ([]string, []string, error) {
+	out, err := runGit(repo, "for-each-ref", "--format=%(refname:short)", "refs/heads")
+	if err != nil {
+		return nil, nil, err
+	}
+	var features, defaults []string
+	for _, branch := range strings.Fields(out) {
+		if defaultBranches[branch] {
+			defaults = append(defaults, branch)
+		} else {
+			features = append(features, branch)
+		}
+	}
+	return features, defaults, nil
+}
+
+// repoCommits groups the commits of a repository by the branch they were
+// made on. Merges are left out and fixups are folded into their commit.
+func repoCommits(repo string, emails []string, since time.Time) ([]commitGroup, error) {
+	authors := make(map[string]bool)
+	for _, email := range emails {
+		authors[strings.ToLower(strings.TrimSpace(email))] = true
+	}
+	if len(authors) == 0 {
+		email, err := runGit(repo, "config", "user.email")
+		if err != nil || strings.TrimSpace(email) == "" {
+			return nil, fmt.Errorf("no author email: set git.emails in config.json or user.email in git")
+		}
+		authors[strings.ToLower(strings.TrimSpace(email))] = true
+	}
+
+	features, defaults, err := localBranches(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var groups []commitGroup
+	for _, branch := range append(features, defaults...) {
+		args := []string{"log", "--no-merges", "--reverse", "--since=" + since.Format(time.RFC3339), "--format=%H%x1f%ae%x1f%s", "refs/heads/" + branch}
+		// A feature branch shows only its own commits, not those it was
+		// started from; once merged, they count as the default branch's
+		if !defaultBranches[branch] {
+			for _, base := range defaults {
+				args = append(args, "^refs/heads/"+base)
+			}
+		}
+		out, err := runGit(repo, append(args, "--")...)
+		if err != nil {
+			return nil, err
+		}
+
+		group := commitGroup{repo: repo, branch: branch}
+		subjects := make(map[string]bool)
+		for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+			fields := strings.SplitN(line, "\x1f", 3)
+			if len(fields) != 3 || seen[fields[0]] {
+				continue
+			}
+			seen[fields[0]] = true
+			if !authors[strings.ToLower(fields[1])] {
+				continue
+			}
+
+			subject := squashSubject(fields[2])
+			if subject == "" || subjects[subject] {
+				continue
+			}
+			subjects[subject] = true
+			group.subjects = append(group.subjects, subject)
+		}
+		if len(group.subjects) > 0 {
+			groups = append(groups, group)
+		}
+	}
+	return groups, nil
+}
+
+// squashSubject strips the "fixup! " and similar prefixes that autosquash
+// uses, so a fixup reads as the commit it fixes
+func squashSubject(subject string) string {
+	for {
+		trimmed := subject
+		for _, prefix := range []string{"fixup! ", "squash! ", "amend! "} {
+			trimmed = strings.TrimPrefix(trimmed, prefix)
+		}
+		if trimmed == subject {
+			return strings.TrimSpace(subject)
+		}
+		subject = trimmed
+	}
+}
@@ -2,7 +2,12 @@ module github.com/ryanirish/slack-standup-updater
 
 go 1.24.2
 
+require (
+	github.com/slack-go/slack v0.16.0
+	golang.org/x/crypto v0.48.0
+)
+
 require (
 	github.com/gorilla/websocket v1.4.2 // indirect
-	github.com/slack-go/slack v0.16.0 // indirect
+	golang.org/x/sys v0.41.0 // indirect
 )
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// historyFile records every posted standup, one JSON object per line. It is
+// only ever appended to, so a crash can at worst leave a torn last line.
+const historyFile = "history.jsonl"
+
+// historyIDFormat turns the time of posting into the ID of a standup
+const historyIDFormat = "20060102-150405"
+
+// HistoryEntry is one posted standup
+type HistoryEntry struct {
+	ID           string          `json:"id"`
+	Posted       int64           `json:"posted"` // Unix time
+	Profile      string          `json:"profile,omitempty"`
+	UserID       string          `json:"user_id,omitempty"`
+	TeamID       string          `json:"team_id,omitempty"`
+	Destination  string          `json:"destination,omitempty"` // Named destination from config.json
+	Conversation string          `json:"conversation"`          // e.g. "#team-standup"
+	ChannelID    string          `json:"channel_id"`
+	ThreadTS     string          `json:"thread_ts,omitempty"`
+	MessageTS    string          `json:"message_ts"`
+	Permalink    string          `json:"permalink,omitempty"`
+	Answers      []HistoryAnswer `json:"answers"`
+	Text         string          `json:"text"` // The message as posted
+}
+
+// HistoryAnswer is the answer to one question, with the prompt as it was
+// asked, so old entries still read right after the questions change
+type HistoryAnswer struct {
+	ID     string `json:"id"`
+	Prompt string `json:"prompt"`
+	Answer string `json:"answer"`
+}
+
+// answer returns the answer to a question by ID, or "" if it was not asked
+func (e HistoryEntry) answer(id string) string {
+	for _, a := range e.Answers {
+		if a.ID == id {
+			return a.Answer
+		}
+	}
+	return ""
+}
+
+// newHistoryEntry records the answers in question order
+func newHistoryEntry(questions []Question, answers map[string]string, text string, posted time.Time) HistoryEntry {
+	entry := HistoryEntry{
+		ID:     posted.Format(historyIDFormat),
+		Posted: posted.Unix(),
+		Text:   text,
+	}
+	for _, q := range questions {
+		entry.Answers = append(entry.Answers, HistoryAnswer{ID: q.ID, Prompt: q.Prompt, Answer: answers[q.ID]})
+	}
+	return entry
+}
+
+// recordStandup fills in where a posted standup went and appends it to the
+// history. The standup is already posted, so failures are only warnings.
+func recordStandup(p *prompter, api *slack.Client, dir *directory, entry HistoryEntry) {
+	entry.Conversation = dir.conversationName(entry.ChannelID)
+
+	permalink, err := api.GetPermalink(&slack.PermalinkParameters{Channel: entry.ChannelID, Ts: entry.MessageTS})
+	if err != nil {
+		p.printInfo(fmt.Sprintf("Warning: Could not get a link to the posted message: %v", err))
+	}
+	entry.Permalink = permalink
+
+	if err := appendHistory(entry); err != nil {
+		p.printInfo(fmt.Sprintf("Warning: Could not save the standup to the history: %v", err))
+	}
+}
+
+// appendHistory adds an entry to the end of history.jsonl
+func appendHistory(entry HistoryEntry) error {
+	path, err := configPath(historyFile)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	// Start on a line of its own after a torn last line
+	if info, statErr := file.Stat(); statErr == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, readErr := file.ReadAt(last, info.Size()-1); readErr == nil && last[0] != '\n' {
+			data = append([]byte{'\n'}, data...)
+		}
+	}
+	_, err = file.Write(append(data, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// loadHistory reads every recorded standup, oldest first, and how many
+// lines could not be read
+func loadHistory() ([]HistoryEntry, int, error) {
+	path, err := configPath(historyFile)
+	if err != nil {
+		return nil, 0, err
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	var entries []HistoryEntry
+	skipped := 0
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var entry HistoryEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			skipped++
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("reading %s: %v", path, err)
+	}
+	return entries, skipped, nil
+}
+
+// profileName resolves the profile a standup is posted with; a token from
+// SLACK_TOKEN belongs to no profile
+func profileName(p *prompter, profile string) string {
+	if os.Getenv("SLACK_TOKEN") != "" {
+		return ""
+	}
+	store, err := loadTokenStore(p)
+	if err != nil {
+		return profile
+	}
+	return store.resolve(profile)
+}
+
+// readHistory loads the history for the history and show commands. Lines
+// that could not be read are mentioned, except in JSON meant for scripts.
+func readHistory(p *prompter, asJSON bool) ([]HistoryEntry, error) {
+	entries, skipped, err := loadHistory()
+	if err != nil {
+		return nil, err
+	}
+	if skipped > 0 && !asJSON {
+		p.printInfo(fmt.Sprintf("Warning: Skipped %d unreadable line(s) in the history", skipped))
+	}
+	return entries, nil
+}
+
+// relativeDayRe matches "3d" and "2w", days or weeks ago
+var relativeDayRe = regexp.MustCompile(`^(\d+)([dw])$`)
+
+// parseDay reads a day as YYYY-MM-DD, "today", "yesterday" or a number of
+// days or weeks ago such as "3d" or "2w", and returns its start
+func parseDay(value string, now time.Time) (time.Time, error) {
+	today := startOfDay(now)
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	switch value {
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+	if match := relativeDayRe.FindStringSubmatch(value); match != nil {
+		n, _ := strconv.Atoi(match[1])
+		if match[2] == "w" {
+			n *= 7
+		}
+		return today.AddDate(0, 0, -n), nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD, today, yesterday, or e.g. 3d or 2w", value)
+	}
+	return day, nil
+}
+
+// matchesDestination reports whether an entry went to the named destination,
+// conversation (with or without "#") or channel ID
+func (e HistoryEntry) matchesDestination(to string) bool {
+	to = strings.TrimSpace(to)
+	return strings.EqualFold(e.Destination, to) || strings.EqualFold(e.ChannelID, to) ||
+		strings.EqualFold(strings.TrimPrefix(e.Conversation, "#"), strings.TrimPrefix(to, "#"))
+}
+
+// printHistoryJSON writes entries as an indented JSON array for scripts
+func printHistoryJSON(p *prompter, entries []HistoryEntry) error {
+	if entries == nil {
+		entries = []HistoryEntry{}
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(p.writer, string(data))
+	return nil
+}
+
+// runHistory implements `standup history`: list the recorded standups,
+// oldest first
+func runHistory(p *prompter, args []string) error {
+	var since, to string
+	var asJSON bool
+
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	fs.StringVar(&since, "since", "", "only standups from this day on: YYYY-MM-DD, today, yesterday, or e.g. 3d or 2w")
+	fs.StringVar(&to, "to", "", "only standups posted to this named destination, channel or channel ID")
+	fs.BoolVar(&asJSON, "json", false, "print the standups as JSON")
+	fs.Usage = func() {
+		printFlagUsage(fs.Output(), "standup history [flags]", fs)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	var from time.Time
+	if since != "" {
+		day, err := parseDay(since, time.Now())
+		if err != nil {
+			return err
+		}
+		from = day
+	}
+
+	entries, err := readHistory(p, asJSON)
+	if err != nil {
+		return err
+	}
+
+	var matched []HistoryEntry
+	for _, entry := range entries {
+		if time.Unix(entry.Posted, 0).Before(from) {
+			continue
+		}
+		if to != "" && !entry.matchesDestination(to) {
+			continue
+		}
+		matched = append(matched, entry)
+	}
+
+	if asJSON {
+		return printHistoryJSON(p, matched)
+	}
+	if len(matched) == 0 {
+		if len(entries) == 0 {
+			p.printInfo("No standups recorded yet.")
+		} else {
+			p.printInfo("No standups match; try an earlier --since or another --to.")
+		}
+		return nil
+	}
+	for _, entry := range matched {
+		fmt.Fprintf(p.writer, "%s\t%s\t%s\t%s\n", entry.ID, time.Unix(entry.Posted, 0).Format("Mon Jan 2 15:04"), entry.describeDestination(), truncate(entry.summary(), 50))
+	}
+	return nil
+}
+
+// describeDestination names where an entry went, with the named
+// destination when one was used
+func (e HistoryEntry) describeDestination() string {
+	to := orUnknown(e.Conversation)
+	if e.Destination != "" {
+		to = e.Destination + " (" + to + ")"
+	}
+	if e.ThreadTS != "" {
+		to += " thread"
+	}
+	return to
+}
+
+// summary is the first bullet point of an entry, to tell entries apart
+func (e HistoryEntry) summary() string {
+	for _, a := range e.Answers {
+		if line, _, _ := strings.Cut(strings.TrimSpace(a.Answer), "\n"); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// runShow implements `standup show <date|id>`: print the standups posted on
+// a day, or the one with the given ID
+func runShow(p *prompter, args []string) error {
+	var asJSON bool
+
+	fs := flag.NewFlagSet("show", flag.ContinueOnError)
+	fs.BoolVar(&asJSON, "json", false, "print the standups as JSON")
+	fs.Usage = func() {
+		printFlagUsage(fs.Output(), "standup show [flags] <id|YYYY-MM-DD|today|yesterday|last>", fs)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	// Flags may also follow the date or ID
+	var ref string
+	if fs.NArg() > 0 {
+		ref = fs.Arg(0)
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return err
+		}
+	}
+	if ref == "" {
+		fs.Usage()
+		return fmt.Errorf("show needs a standup ID or a date")
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	entries, err := readHistory(p, asJSON)
+	if err != nil {
+		return err
+	}
+	matched, err := findHistoryEntries(entries, ref, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		return printHistoryJSON(p, matched)
+	}
+	for _, entry := range matched {
+		p.printHeader(fmt.Sprintf("%s · %s", entry.ID, time.Unix(entry.Posted, 0).Format("Mon Jan 2 2006 15:04")))
+		p.printInfo("To: " + entry.describeDestination())
+		if entry.Profile != "" {
+			p.printInfo("Profile: " + entry.Profile)
+		}
+		if entry.UserID != "" {
+			p.printInfo(fmt.Sprintf("As: %s in %s", entry.UserID, entry.TeamID))
+		}
+		if entry.Permalink != "" {
+			p.printInfo("Link: " + entry.Permalink)
+		}
+		p.printDivider()
+		fmt.Fprint(p.writer, renderMrkdwn(entry.Text))
+		p.printDivider()
+	}
+	return nil
+}
+
+// findHistoryEntries returns the entry with the given ID, the latest one for
+// "last", or every entry posted on the given day
+func findHistoryEntries(entries []HistoryEntry, ref string, now time.Time) ([]HistoryEntry, error) {
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no standups recorded yet")
+	}
+
+	for _, entry := range entries {
+		if entry.ID == ref {
+			return []HistoryEntry{entry}, nil
+		}
+	}
+	if ref == "last" || ref == "latest" {
+		return entries[len(entries)-1:], nil
+	}
+
+	day, err := parseDay(ref, now)
+	if err != nil {
+		return nil, fmt.Errorf("no standup with ID %q, and it is not a date either", ref)
+	}
+	next := day.AddDate(0, 0, 1)
+
+	var matched []HistoryEntry
+	for _, entry := range entries {
+		posted := time.Unix(entry.Posted, 0)
+		if !posted.Before(day) && posted.Before(next) {
+			matched = append(matched, entry)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("no standups recorded on %s", day.Format("Mon Jan 2 2006"))
+	}
+	return matched, nil
+}
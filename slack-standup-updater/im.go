@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/slack-go/slack"
+)
+
+// slackbotUserID is the user ID Slackbot has in every workspace
+const slackbotUserID = "USLACKBOT"
+
+// findIM returns the ID of the direct message conversation with a user,
+// going through every page of the user's IMs
+func findIM(api *slack.Client, userID string) (string, error) {
+	params := &slack.GetConversationsParameters{
+		Types: []string{"im"},
+		Limit: 200,
+	}
+
+	count := 0
+	for {
+		channels, cursor, err := api.GetConversations(params)
+		if err != nil {
+			return "", fmt.Errorf("listing direct messages: %v%s", err, scopeHint(err, "im:read"))
+		}
+		for _, channel := range channels {
+			if channel.User == userID {
+				return channel.ID, nil
+			}
+		}
+		count += len(channels)
+
+		if cursor == "" {
+			return "", fmt.Errorf("none of your %d direct message conversations is with %s", count, userID)
+		}
+		params.Cursor = cursor
+	}
+}
+
+// selfDM returns the ID of the user's DM with themselves. conversations.open
+// finds it directly; listing the IMs is the fallback when that is refused.
+func selfDM(api *slack.Client, userID string) (string, error) {
+	channel, _, _, openErr := api.OpenConversation(&slack.OpenConversationParameters{
+		Users: []string{userID},
+	})
+	if openErr == nil {
+		return channel.ID, nil
+	}
+
+	channelID, err := findIM(api, userID)
+	if err != nil {
+		return "", fmt.Errorf("conversations.open failed (%v) and %v", openErr, err)
+	}
+	return channelID, nil
+}
@@ -0,0 +1,437 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// inputHistoryFile keeps the bullet points typed for each question, so they
+// can be recalled with the up arrow on later runs
+const inputHistoryFile = "input_history.json"
+
+// inputHistoryLimit is how many bullet points are kept per question
+const inputHistoryLimit = 100
+
+const (
+	bracketedPasteOn  = "\033[?2004h"
+	bracketedPasteOff = "\033[?2004l"
+	pasteEnd          = "\033[201~"
+)
+
+// errGoBack is returned by answerQuestion when the user asks to go back to
+// the previous question
+var errGoBack = errors.New("back to the previous question")
+
+// lineResult is how the user finished a line in the editor
+type lineResult int
+
+const (
+	lineEntered  lineResult = iota // Enter; an empty line finishes the question
+	lineFinished                   // Ctrl-D on an empty line
+	lineBack                       // Backspace on an empty line
+	linePasted                     // A pasted block of several lines
+)
+
+// lineEditor edits one line of input in raw mode, like readline
+type lineEditor struct {
+	p       *prompter
+	buf     []rune
+	pos     int      // Cursor position in buf
+	history []string // Oldest first
+	browse  int      // Entry of history shown; len(history) is the new line
+	typed   []rune   // The new line, kept while browsing history
+}
+
+// read edits the line until the user finishes it and returns how, with the
+// trimmed text
+func (e *lineEditor) read() (lineResult, string, error) {
+	e.browse = len(e.history)
+	e.pos = len(e.buf)
+	e.redraw()
+
+	for {
+		key, err := e.p.readKey()
+		if err != nil {
+			fmt.Fprintln(e.p.writer)
+			return lineEntered, "", err
+		}
+
+		switch key {
+		case keyEnter:
+			fmt.Fprintln(e.p.writer)
+			return lineEntered, strings.TrimSpace(string(e.buf)), nil
+		case keyInterrupt:
+			fmt.Fprintln(e.p.writer)
+			return lineEntered, "", errCancelled
+		case keyBackspace:
+			if len(e.buf) == 0 {
+				return lineBack, "", nil
+			}
+			if e.pos > 0 {
+				e.buf = append(e.buf[:e.pos-1], e.buf[e.pos:]...)
+				e.pos--
+			}
+		case keyDelete:
+			if len(e.buf) == 0 {
+				fmt.Fprintln(e.p.writer)
+				return lineFinished, "", nil
+			}
+			if e.pos < len(e.buf) {
+				e.buf = append(e.buf[:e.pos], e.buf[e.pos+1:]...)
+			}
+		case keyLeft:
+			if e.pos > 0 {
+				e.pos--
+			}
+		case keyRight:
+			if e.pos < len(e.buf) {
+				e.pos++
+			}
+		case keyHome:
+			e.pos = 0
+		case keyEnd:
+			e.pos = len(e.buf)
+		case keyWordLeft:
+			e.pos = wordStart(e.buf, e.pos)
+		case keyWordRight:
+			e.pos = wordEnd(e.buf, e.pos)
+		case keyDeleteWord:
+			start := wordStart(e.buf, e.pos)
+			e.buf = append(e.buf[:start], e.buf[e.pos:]...)
+			e.pos = start
+		case keyClearLine:
+			e.buf = append([]rune(nil), e.buf[e.pos:]...)
+			e.pos = 0
+		case keyKillEnd:
+			e.buf = e.buf[:e.pos]
+		case keyUp:
+			e.recall(e.browse - 1)
+		case keyDown:
+			e.recall(e.browse + 1)
+		case keyPasteStart:
+			pasted, err := e.p.readPaste()
+			if err != nil {
+				fmt.Fprintln(e.p.writer)
+				return lineEntered, "", err
+			}
+			if !strings.Contains(pasted, "\n") {
+				e.insert([]rune(pasted))
+				break
+			}
+			// Several lines stay together as one bullet point, with what
+			// was typed before and after the cursor around them
+			block := string(e.buf[:e.pos]) + pasted + string(e.buf[e.pos:])
+			bullet := pastedBullet(block)
+			fmt.Fprint(e.p.writer, "\r\033[K")
+			e.p.printBullet(bullet)
+			return linePasted, bullet, nil
+		default:
+			if unicode.IsPrint(key) {
+				e.insert([]rune{key})
+			}
+		}
+		e.redraw()
+	}
+}
+
+// insert types text at the cursor
+func (e *lineEditor) insert(text []rune) {
+	rest := append([]rune(nil), e.buf[e.pos:]...)
+	e.buf = append(append(e.buf[:e.pos], text...), rest...)
+	e.pos += len(text)
+}
+
+// recall shows history entry i in place of the line; past the newest entry
+// is the line being typed
+func (e *lineEditor) recall(i int) {
+	if i < 0 || i > len(e.history) {
+		return
+	}
+	if e.browse == len(e.history) {
+		e.typed = e.buf
+	}
+	e.browse = i
+	if i == len(e.history) {
+		e.buf = e.typed
+	} else {
+		e.buf = []rune(e.history[i])
+	}
+	e.pos = len(e.buf)
+}
+
+// redraw writes the prompt and the line over the current terminal line and
+// puts the cursor back where it belongs
+func (e *lineEditor) redraw() {
+	fmt.Fprint(e.p.writer, "\r\033[K")
+	e.p.printPrompt(">")
+	fmt.Fprint(e.p.writer, string(e.buf))
+	if back := len(e.buf) - e.pos; back > 0 {
+		fmt.Fprintf(e.p.writer, "\033[%dD", back)
+	}
+}
+
+// wordStart is where the word before pos starts, skipping spaces first
+func wordStart(buf []rune, pos int) int {
+	for pos > 0 && unicode.IsSpace(buf[pos-1]) {
+		pos--
+	}
+	for pos > 0 && !unicode.IsSpace(buf[pos-1]) {
+		pos--
+	}
+	return pos
+}
+
+// wordEnd is where the word after pos ends, skipping spaces first
+func wordEnd(buf []rune, pos int) int {
+	for pos < len(buf) && unicode.IsSpace(buf[pos]) {
+		pos++
+	}
+	for pos < len(buf) && !unicode.IsSpace(buf[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// readPaste reads a bracketed paste up to its end marker, with line endings
+// made "\n" and the blank lines around it dropped
+func (p *prompter) readPaste() (string, error) {
+	marker := []rune(pasteEnd)
+	var pasted []rune
+	for {
+		r, _, err := p.reader.ReadRune()
+		if err != nil {
+			return "", errNoInput
+		}
+		pasted = append(pasted, r)
+		if n := len(pasted) - len(marker); n >= 0 && string(pasted[n:]) == pasteEnd {
+			pasted = pasted[:n]
+			break
+		}
+	}
+
+	text := strings.ReplaceAll(string(pasted), "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	text = strings.ReplaceAll(text, "\t", "    ")
+	return strings.Trim(text, "\n"), nil
+}
+
+// pastedBullet turns a block of lines into one bullet point: the lines after
+// the first are indented, which marks them as continuing it
+func pastedBullet(block string) string {
+	var lines []string
+	for _, line := range strings.Split(block, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n  ")
+}
+
+// splitBullets cuts an answer into its bullet points, keeping indented
+// continuation lines with the bullet point above them
+func splitBullets(answer string) []string {
+	var bullets []string
+	for _, line := range strings.Split(answer, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if len(bullets) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			bullets[len(bullets)-1] += "\n  " + strings.TrimSpace(line)
+			continue
+		}
+		bullets = append(bullets, strings.TrimSpace(line))
+	}
+	return bullets
+}
+
+// printBullet shows an entered bullet point the way it was typed, with its
+// continuation lines under the text
+func (p *prompter) printBullet(bullet string) {
+	for i, line := range strings.Split(bullet, "\n") {
+		if i == 0 {
+			p.printPrompt(">")
+			fmt.Fprintln(p.writer, line)
+		} else {
+			fmt.Fprintln(p.writer, "   "+line)
+		}
+	}
+}
+
+// answerQuestion asks a question and returns the answer, one bullet point
+// per line. On a terminal the bullet points are typed in the line editor,
+// starting from those in current; Backspace on an empty line reopens the
+// previous one, and before the first one returns errGoBack if canGoBack.
+func (p *prompter) answerQuestion(q Question, current string, canGoBack bool) (string, error) {
+	p.printQuestion(q.Prompt)
+	hint := "Enter each bullet point on a new line. Press Enter twice when done."
+	if q.Required {
+		hint = "Required. " + hint
+	}
+
+	if !p.interactive() {
+		p.printInfo("(" + hint + ")")
+		return p.readAnswer(q, splitBullets(current))
+	}
+	state, err := enableRawMode(p.inFd)
+	if err != nil {
+		p.printInfo("(" + hint + ")")
+		return p.readAnswer(q, splitBullets(current))
+	}
+	fmt.Fprint(p.writer, bracketedPasteOn)
+	defer func() {
+		fmt.Fprint(p.writer, bracketedPasteOff)
+		restoreTerminal(p.inFd, state)
+	}()
+
+	if canGoBack {
+		hint += " ↑/↓ recall earlier answers; Backspace on an empty line edits the one above or goes back a question."
+	} else {
+		hint += " ↑/↓ recall earlier answers; Backspace on an empty line edits the one above."
+	}
+	p.printInfo("(" + hint + ")")
+
+	inputs := loadInputHistory()
+	history := inputs[q.ID]
+
+	bullets := splitBullets(current)
+	for _, bullet := range bullets {
+		p.printBullet(bullet)
+	}
+
+	var reopened string
+	for {
+		editor := &lineEditor{p: p, buf: []rune(reopened), history: history}
+		reopened = ""
+
+		result, text, err := editor.read()
+		if err != nil {
+			return "", err
+		}
+
+		switch result {
+		case lineBack:
+			if len(bullets) > 0 {
+				last := bullets[len(bullets)-1]
+				bullets = bullets[:len(bullets)-1]
+
+				// Erase it from the screen and edit it as a single line
+				fmt.Fprintf(p.writer, "\r\033[%dA\033[J", strings.Count(last, "\n")+1)
+				reopened = strings.ReplaceAll(last, "\n  ", " ")
+			} else if canGoBack {
+				fmt.Fprint(p.writer, "\r\033[K")
+				return "", errGoBack
+			}
+			continue
+		case linePasted:
+			bullets = append(bullets, text)
+			continue
+		}
+
+		if text != "" {
+			bullets = append(bullets, text)
+			history = appendInputHistory(history, text)
+			if result == lineEntered {
+				continue
+			}
+		}
+		if len(bullets) == 0 && q.Required {
+			p.printInfo("This question needs at least one bullet point.")
+			continue
+		}
+		break
+	}
+
+	inputs[q.ID] = history
+	if err := saveInputHistory(inputs); err != nil {
+		p.printInfo(fmt.Sprintf("Warning: could not save input history: %v", err))
+	}
+	return strings.Join(bullets, "\n"), nil
+}
+
+// readAnswer reads an answer line by line after the given bullet points, for
+// input that is not a terminal. Required questions are asked again until at
+// least one line is given.
+func (p *prompter) readAnswer(q Question, lines []string) (string, error) {
+	for _, line := range lines {
+		p.printBullet(line)
+	}
+	p.printPrompt(">")
+
+	for {
+		line, err := p.readLine()
+		if err == errNoInput && len(lines) > 0 {
+			// Input ended mid-answer, keep what we have
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if line == "" {
+			if len(lines) == 0 && q.Required {
+				p.printInfo("This question needs at least one bullet point.")
+				p.printPrompt(">")
+				continue
+			}
+			break
+		}
+
+		lines = append(lines, line)
+		p.printPrompt(">")
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// appendInputHistory adds a bullet point as the newest entry, dropping an
+// older copy of it and the oldest entries past the limit
+func appendInputHistory(history []string, entry string) []string {
+	kept := make([]string, 0, len(history)+1)
+	for _, old := range history {
+		if old != entry {
+			kept = append(kept, old)
+		}
+	}
+	kept = append(kept, entry)
+	if len(kept) > inputHistoryLimit {
+		kept = kept[len(kept)-inputHistoryLimit:]
+	}
+	return kept
+}
+
+// loadInputHistory reads the bullet points typed so far, keyed by question
+// ID; a missing or unreadable file is an empty history
+func loadInputHistory() map[string][]string {
+	inputs := make(map[string][]string)
+
+	path, err := configPath(inputHistoryFile)
+	if err != nil {
+		return inputs
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return inputs
+	}
+	json.Unmarshal(data, &inputs)
+	if inputs == nil {
+		inputs = make(map[string][]string)
+	}
+	return inputs
+}
+
+// saveInputHistory writes the input history
+func saveInputHistory(inputs map[string][]string) error {
+	path, err := configPath(inputHistoryFile)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(inputs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0600)
+}
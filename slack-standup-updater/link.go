@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// SlackLink is what a Slack link points at. Links to a whole channel leave
+// the timestamps empty.
+type SlackLink struct {
+	TeamID    string // Workspace ID, when the link carries it
+	ChannelID string // Channel, DM (D…) or group (G…) ID
+	MessageTS string // The linked message
+	ThreadTS  string // Root of the thread the message is in; a reply goes here
+}
+
+var (
+	// e.g. ******.slack.com/archives/C048ECCB75H/p1743724813501239
+	archivesPathRe = regexp.MustCompile(`^/archives/([CDG][A-Z0-9]+)(?:/p(\d{7,}))?/?$`)
+
+	// e.g. app.slack.com/client/T0123ABCD/C048ECCB75H/thread/C048ECCB75H-1743724813.501239
+	clientPathRe = regexp.MustCompile(`^/client/([TE][A-Z0-9]+)/([CDG][A-Z0-9]+)(?:/thread/([CDG][A-Z0-9]+)-(\d+\.\d+))?/?$`)
+
+	conversationIDRe = regexp.MustCompile(`^[CDG][A-Z0-9]+$`)
+	teamIDRe         = regexp.MustCompile(`^[TE][A-Z0-9]+$`)
+	timestampRe      = regexp.MustCompile(`^\d+\.\d+$`)
+)
+
+// parseSlackLink reads a message, thread or channel link in any of the
+// forms Slack hands out: "Copy link" permalinks (with thread_ts on replies),
+// app.slack.com client URLs and slack:// deep links
+func parseSlackLink(link string) (SlackLink, error) {
+	// Links pasted from a Slack message are wrapped in <...>
+	raw := strings.Trim(strings.TrimSpace(link), "<>")
+	if raw == "" {
+		return SlackLink{}, fmt.Errorf("empty link")
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return SlackLink{}, fmt.Errorf("invalid Slack link: %v", err)
+	}
+
+	switch {
+	case u.Scheme == "slack":
+		return parseDeepLink(u)
+	case strings.HasPrefix(u.Path, "/client/"):
+		return parseClientLink(u)
+	case strings.HasPrefix(u.Path, "/archives/"):
+		return parsePermalink(u)
+	default:
+		return SlackLink{}, fmt.Errorf("invalid Slack link format")
+	}
+}
+
+// parsePermalink reads an /archives/ link. The message of a reply's link is
+// the reply itself; its thread comes from the thread_ts parameter.
+func parsePermalink(u *url.URL) (SlackLink, error) {
+	matches := archivesPathRe.FindStringSubmatch(u.Path)
+	if matches == nil {
+		return SlackLink{}, fmt.Errorf("invalid Slack link format")
+	}
+
+	result := SlackLink{ChannelID: matches[1]}
+	if matches[2] == "" {
+		return result, nil
+	}
+
+	// Format timestamp from p1743724813501239 to 1743724813.501239
+	result.MessageTS = matches[2][:len(matches[2])-6] + "." + matches[2][len(matches[2])-6:]
+	result.ThreadTS = result.MessageTS
+
+	if threadTS := u.Query().Get("thread_ts"); threadTS != "" {
+		if !timestampRe.MatchString(threadTS) {
+			return SlackLink{}, fmt.Errorf("invalid thread_ts %q", threadTS)
+		}
+		result.ThreadTS = threadTS
+	}
+	return result, nil
+}
+
+// parseClientLink reads a link from the Slack web client, either to a
+// channel or to a thread opened in the side panel
+func parseClientLink(u *url.URL) (SlackLink, error) {
+	matches := clientPathRe.FindStringSubmatch(u.Path)
+	if matches == nil {
+		return SlackLink{}, fmt.Errorf("invalid Slack client link format")
+	}
+
+	result := SlackLink{TeamID: matches[1], ChannelID: matches[2]}
+	if matches[3] == "" {
+		return result, nil
+	}
+	if matches[3] != matches[2] {
+		return SlackLink{}, fmt.Errorf("thread is in %s but the link is for %s", matches[3], matches[2])
+	}
+
+	result.MessageTS = matches[4]
+	result.ThreadTS = matches[4]
+	return result, nil
+}
+
+// parseDeepLink reads a slack://channel?team=…&id=…&message=… link
+func parseDeepLink(u *url.URL) (SlackLink, error) {
+	if u.Host != "channel" {
+		return SlackLink{}, fmt.Errorf("unsupported slack:// link %q, expected slack://channel", u.Host)
+	}
+
+	query := u.Query()
+	result := SlackLink{
+		TeamID:    query.Get("team"),
+		ChannelID: query.Get("id"),
+		MessageTS: query.Get("message"),
+		ThreadTS:  query.Get("thread_ts"),
+	}
+
+	if !conversationIDRe.MatchString(result.ChannelID) {
+		return SlackLink{}, fmt.Errorf("invalid channel ID %q in link", result.ChannelID)
+	}
+	if result.TeamID != "" && !teamIDRe.MatchString(result.TeamID) {
+		return SlackLink{}, fmt.Errorf("invalid team ID %q in link", result.TeamID)
+	}
+	for _, ts := range []string{result.MessageTS, result.ThreadTS} {
+		if ts != "" && !timestampRe.MatchString(ts) {
+			return SlackLink{}, fmt.Errorf("invalid timestamp %q in link", ts)
+		}
+	}
+	if result.ThreadTS == "" {
+		result.ThreadTS = result.MessageTS
+	}
+	return result, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import "testing"
+
+func TestParseSlackLink(t *testing.T) {
+	tests := []struct {
+		name    string
+		link    string
+		want    SlackLink
+		wantErr bool
+	}{
+		{
+			name: "permalink",
+			link: "https://example.slack.com/archives/C048ECCB75H/p1743724813501239",
+			want: SlackLink{ChannelID: "C048ECCB75H", MessageTS: "1743724813.501239", ThreadTS: "1743724813.501239"},
+		},
+		{
+			name: "permalink without scheme",
+			link: "example.slack.com/archives/C048ECCB75H/p1743724813501239",
+			want: SlackLink{ChannelID: "C048ECCB75H", MessageTS: "1743724813.501239", ThreadTS: "1743724813.501239"},
+		},
+		{
+			name: "permalink pasted from a message",
+			link: "  <https://example.slack.com/archives/C048ECCB75H/p1743724813501239>\n",
+			want: SlackLink{ChannelID: "C048ECCB75H", MessageTS: "1743724813.501239", ThreadTS: "1743724813.501239"},
+		},
+		{
+			name: "reply permalink threads under the parent",
+			link: "https://example.slack.com/archives/C048ECCB75H/p1743725000123456?thread_ts=1743724813.501239&cid=C048ECCB75H",
+			want: SlackLink{ChannelID: "C048ECCB75H", MessageTS: "1743725000.123456", ThreadTS: "1743724813.501239"},
+		},
+		{
+			name: "DM permalink",
+			link: "https://example.slack.com/archives/D01ABCDEF23/p1743724813501239",
+			want: SlackLink{ChannelID: "D01ABCDEF23", MessageTS: "1743724813.501239", ThreadTS: "1743724813.501239"},
+		},
+		{
+			name: "group permalink",
+			link: "https://example.slack.com/archives/G01ABCDEF23/p1743724813501239",
+			want: SlackLink{ChannelID: "G01ABCDEF23", MessageTS: "1743724813.501239", ThreadTS: "1743724813.501239"},
+		},
+		{
+			name: "channel permalink",
+			link: "https://example.slack.com/archives/C048ECCB75H",
+			want: SlackLink{ChannelID: "C048ECCB75H"},
+		},
+		{
+			name: "client thread",
+			link: "https://app.slack.com/client/T0123ABCD/C048ECCB75H/thread/C048ECCB75H-1743724813.501239",
+			want: SlackLink{TeamID: "T0123ABCD", ChannelID: "C048ECCB75H", MessageTS: "1743724813.501239", ThreadTS: "1743724813.501239"},
+		},
+		{
+			name: "client channel",
+			link: "https://app.slack.com/client/T0123ABCD/C048ECCB75H",
+			want: SlackLink{TeamID: "T0123ABCD", ChannelID: "C048ECCB75H"},
+		},
+		{
+			name: "client DM thread",
+			link: "https://app.slack.com/client/E0123ABCD/D01ABCDEF23/thread/D01ABCDEF23-1743724813.501239",
+			want: SlackLink{TeamID: "E0123ABCD", ChannelID: "D01ABCDEF23", MessageTS: "1743724813.501239", ThreadTS: "1743724813.501239"},
+		},
+		{
+			name: "deep link to a message",
+			link: "slack://channel?team=T0123ABCD&id=C048ECCB75H&message=1743724813.501239",
+			want: SlackLink{TeamID: "T0123ABCD", ChannelID: "C048ECCB75H", MessageTS: "1743724813.501239", ThreadTS: "1743724813.501239"},
+		},
+		{
+			name: "deep link to a reply",
+			link: "slack://channel?team=T0123ABCD&id=G01ABCDEF23&message=1743725000.123456&thread_ts=1743724813.501239",
+			want: SlackLink{TeamID: "T0123ABCD", ChannelID: "G01ABCDEF23", MessageTS: "1743725000.123456", ThreadTS: "1743724813.501239"},
+		},
+		{
+			name: "deep link to a channel",
+			link: "slack://channel?team=T0123ABCD&id=C048ECCB75H",
+			want: SlackLink{TeamID: "T0123ABCD", ChannelID: "C048ECCB75H"},
+		},
+		{name: "empty", link: "  ", wantErr: true},
+		{name: "not a Slack link", link: "https://example.com/some/page", wantErr: true},
+		{name: "user ID instead of channel", link: "https://example.slack.com/archives/U0123ABCD/p1743724813501239", wantErr: true},
+		{name: "short timestamp", link: "https://example.slack.com/archives/C048ECCB75H/p123", wantErr: true},
+		{name: "bad thread_ts", link: "https://example.slack.com/archives/C048ECCB75H/p1743724813501239?thread_ts=abc", wantErr: true},
+		{name: "client thread in another channel", link: "https://app.slack.com/client/T0123ABCD/C048ECCB75H/thread/C999-1743724813.501239", wantErr: true},
+		{name: "deep link to a user", link: "slack://user?team=T0123ABCD&id=U0123ABCD", wantErr: true},
+		{name: "deep link without channel", link: "slack://channel?team=T0123ABCD", wantErr: true},
+		{name: "deep link with bad message", link: "slack://channel?team=T0123ABCD&id=C048ECCB75H&message=p1743724813501239", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSlackLink(tt.link)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseSlackLink(%q) = %+v, want an error", tt.link, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSlackLink(%q) returned error: %v", tt.link, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseSlackLink(%q) = %+v, want %+v", tt.link, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// loginOptions changes how a sign-in is carried out when one is needed
+type loginOptions struct {
+	headless bool // Paste the redirect URL back instead of running a callback server
+	port     int  // Local port of the callback server
+}
+
+// addFlags registers the sign-in flags shared by every command that may log in
+func (o *loginOptions) addFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&o.headless, "headless", isHeadlessSession(),
+		"sign in without a local browser by pasting back the redirect URL (default on over SSH)")
+	fs.IntVar(&o.port, "port", defaultCallbackPort,
+		"local port for the OAuth callback, must match the app's redirect URL")
+}
+
+// isHeadlessSession reports whether no local browser can be opened, i.e. an
+// SSH session without X11 or Wayland forwarding, or STANDUP_HEADLESS is set
+func isHeadlessSession() bool {
+	if value, exists := os.LookupEnv("STANDUP_HEADLESS"); exists {
+		return value != "" && value != "0" && value != "false"
+	}
+
+	_, overSSH := os.LookupEnv("SSH_CONNECTION")
+	return overSSH && os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == ""
+}
+
+// runLogin implements `standup login`, signing in again even when the
+// profile already has a working token. Instead of OAuth, an existing user
+// token can be given directly or through a command that prints it.
+func runLogin(p *prompter, args []string) error {
+	var profile, token, tokenCommand string
+	var opts loginOptions
+
+	fs := flag.NewFlagSet("login", flag.ContinueOnError)
+	fs.StringVar(&profile, "profile", "", "profile to sign in (default: the default profile)")
+	fs.StringVar(&token, "token", "", "use this user token (xoxp-) instead of OAuth; \"-\" to paste it at a prompt")
+	fs.StringVar(&tokenCommand, "token-command", "", "command that prints a user token, run on demand (e.g. \"pass show slack/xoxp\")")
+	opts.addFlags(fs)
+	fs.Usage = func() {
+		printFlagUsage(fs.Output(), "standup login [flags]", fs)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	if token != "" && tokenCommand != "" {
+		return fmt.Errorf("--token cannot be combined with --token-command")
+	}
+
+	store, err := loadTokenStore(p)
+	if err != nil {
+		return err
+	}
+	name := store.resolve(profile)
+	previous := store.Profiles[name]
+
+	switch {
+	case token != "":
+		if token == "-" {
+			if token, err = p.getSecret("Paste your Slack user token (xoxp-...)"); err != nil {
+				return err
+			}
+		}
+		err = saveValidatedToken(p, name, previous, token, "")
+	case tokenCommand != "":
+		token, err = runTokenCommand(p, tokenCommand)
+		if err == nil {
+			err = saveValidatedToken(p, name, previous, token, tokenCommand)
+		}
+	default:
+		_, err = signIn(p, name, previous, opts)
+	}
+	if err != nil {
+		return err
+	}
+
+	p.printSuccess(fmt.Sprintf("Signed in to profile %q", name))
+	return nil
+}
+
+// saveValidatedToken checks a token with auth.test and saves the profile.
+// With a token command only the command is stored, never the token itself.
+func saveValidatedToken(p *prompter, profile string, previous TokenConfig, token, tokenCommand string) error {
+	info, err := validateToken(token)
+	if err != nil {
+		return err
+	}
+
+	config := TokenConfig{
+		UserID:       info.UserID,
+		TeamID:       info.TeamID,
+		Scopes:       info.Scopes,
+		ClientID:     previous.ClientID,
+		ClientSecret: previous.ClientSecret,
+		TokenCommand: tokenCommand,
+	}
+	if tokenCommand == "" {
+		config.AccessToken = token
+	}
+
+	if err := saveTokenConfig(p, profile, config); err != nil {
+		return fmt.Errorf("saving profile: %v", err)
+	}
+
+	p.printSuccess(fmt.Sprintf("Token is valid for %s in %s", info.UserID, info.TeamID))
+	if info.Scopes != "" {
+		p.printInfo("Granted scopes: " + info.Scopes)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// runLogout implements `standup logout`: revoke the profile's token on
+// Slack's side, then remove its stored credentials and generated certificates
+func runLogout(p *prompter, args []string) error {
+	var profile string
+	var localOnly bool
+
+	fs := flag.NewFlagSet("logout", flag.ContinueOnError)
+	fs.StringVar(&profile, "profile", "", "profile to sign out (default: the default profile)")
+	fs.BoolVar(&localOnly, "local", false, "only remove local credentials, without revoking the token on Slack")
+	fs.Usage = func() {
+		printFlagUsage(fs.Output(), "standup logout [flags]", fs)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	store, err := loadTokenStore(p)
+	if err != nil {
+		return err
+	}
+	name := store.resolve(profile)
+	config, exists := store.Profiles[name]
+	if !exists {
+		return fmt.Errorf("profile %q not found", name)
+	}
+
+	p.printHeader("Signing out 👋")
+
+	switch {
+	case localOnly:
+		p.printInfo("Skipping token revocation (--local); the token stays valid on Slack.")
+	case config.TokenCommand != "":
+		// The token belongs to whatever the command reads it from
+		p.printInfo("Not revoking the token from the token command, it is managed outside this tool.")
+	case config.AccessToken != "":
+		if err := revokeToken(p, name, config); err != nil {
+			return err
+		}
+	}
+
+	delete(store.Profiles, name)
+	if store.Default == name {
+		store.Default = ""
+	}
+	if err := saveTokenStore(p, &store); err != nil {
+		return fmt.Errorf("removing profile %q: %v", name, err)
+	}
+	p.printSuccess(fmt.Sprintf("Removed profile %q and its stored credentials", name))
+
+	if err := removeCertificates(p); err != nil {
+		return err
+	}
+
+	// Signing out locks the remaining profiles too, so the key does not
+	// outlive the credentials it was cached for
+	if err := clearUnlockCache(); err != nil {
+		return fmt.Errorf("clearing the unlock cache: %v", err)
+	}
+
+	return nil
+}
+
+// revokeToken calls auth.revoke. A token Slack already considers dead is not
+// an error, but a failed request is, so the local copy is kept for a retry.
+// An expired rotating token is refreshed first and the new one revoked,
+// since its refresh token is still live on Slack's side.
+func revokeToken(p *prompter, profile string, config TokenConfig) error {
+	token := config.AccessToken
+	refreshed := false
+	refresh := func() error {
+		refreshed = true
+		var err error
+		token, err = refreshSavedToken(p, profile, config)
+		return err
+	}
+
+	if config.RefreshToken != "" && config.Expiration != 0 && time.Now().Unix() >= config.Expiration {
+		if err := refresh(); err != nil {
+			return revokeError(p, err, false)
+		}
+	}
+
+	_, err := slack.New(token).SendAuthRevoke("")
+	if err != nil && strings.Contains(err.Error(), "token_expired") && config.RefreshToken != "" && !refreshed {
+		if err := refresh(); err != nil {
+			return revokeError(p, err, false)
+		}
+		_, err = slack.New(token).SendAuthRevoke("")
+	}
+	if err != nil {
+		return revokeError(p, err, config.RefreshToken == "")
+	}
+
+	p.printSuccess("Revoked the token on Slack")
+	return nil
+}
+
+// revokeError reports a failed revocation, or nil when the credential was
+// already dead. An expired token only counts as dead without a refresh token
+// that could still bring it back.
+func revokeError(p *prompter, err error, expiredIsDead bool) error {
+	dead := []string{"invalid_auth", "token_revoked", "account_inactive", "invalid_refresh_token"}
+	if expiredIsDead {
+		dead = append(dead, "token_expired")
+	}
+	for _, code := range dead {
+		if strings.Contains(err.Error(), code) {
+			p.printInfo(fmt.Sprintf("Token was already unusable on Slack (%v)", err))
+			return nil
+		}
+	}
+	return fmt.Errorf("revoking token: %v (nothing was removed; retry, or use --local to only remove local credentials)", err)
+}
+
+// removeCertificates deletes the generated localhost certificate and key;
+// they are recreated on the next browser sign-in
+func removeCertificates(p *prompter) error {
+	dir, err := configPath(certsDir)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("removing certificates: %v", err)
+	}
+
+	p.printSuccess("Removed the generated localhost certificate " + dir)
+	return nil
+}
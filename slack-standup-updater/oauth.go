@@ -0,0 +1,546 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	slackAuthorizeURL = "https://slack.com/oauth/v2/authorize"
+	slackTokenURL     = "https://slack.com/api/oauth.v2.access"
+
+	// tokenRefreshMargin is how long before expiry a rotating token is refreshed
+	tokenRefreshMargin = 5 * time.Minute
+)
+
+// getUserToken gets the user token of a profile ("" for the default) from
+// config or initiates OAuth flow
+func getUserToken(p *prompter, profile string, opts loginOptions) (string, error) {
+	// A token in the environment wins and is never saved
+	if token := os.Getenv("SLACK_TOKEN"); token != "" {
+		p.printInfo("Using the token from SLACK_TOKEN 🔑")
+		info, err := validateToken(token)
+		if err != nil {
+			return "", fmt.Errorf("SLACK_TOKEN: %v", err)
+		}
+		// Kept for this run only, like the token
+		sessionIdentity = &info
+		p.printSuccess(fmt.Sprintf("Token is valid for %s in %s", info.UserID, info.TeamID))
+		if info.Scopes != "" {
+			p.printInfo("Granted scopes: " + info.Scopes)
+		}
+		return token, nil
+	}
+
+	// Check if we have a valid token for this profile
+	config, err := readTokenConfig(p, profile)
+	if err == nil && config.TokenCommand != "" {
+		return tokenFromCommand(p, profile, config)
+	}
+	// Rotating tokens are refreshed a little before they expire
+	if err == nil && config.RefreshToken != "" && config.Expiration != 0 && time.Now().Add(tokenRefreshMargin).Unix() >= config.Expiration {
+		token, refreshErr := refreshSavedToken(p, profile, config)
+		if refreshErr == nil {
+			return token, nil
+		}
+		p.printError(refreshErr.Error())
+	}
+	if err == nil && config.AccessToken != "" && (config.Expiration == 0 || time.Now().Unix() < config.Expiration) {
+		if !strings.HasPrefix(config.AccessToken, "xoxb-") {
+			p.printInfo("Using saved authentication token 🔑")
+			rememberIdentity(config)
+			return config.AccessToken, nil
+		}
+		// Older versions stored a bot token, which cannot post as the user
+		p.printInfo("The saved token is a bot token, so posts would not appear as you. Signing in again...")
+	}
+
+	return signIn(p, profile, config, opts)
+}
+
+// signIn runs the OAuth flow for a profile and saves the resulting token.
+// previous holds the profile's earlier credentials, if any, to reuse the
+// app's client ID and secret.
+func signIn(p *prompter, profile string, previous TokenConfig, opts loginOptions) (string, error) {
+	p.printHeader("Slack Authentication 🔒")
+	p.printInfo("You need to authenticate with Slack.")
+	if opts.headless {
+		p.printInfo("Headless mode: you will open a link in any browser and paste back where it sends you.")
+	} else {
+		p.printInfo("This tool will open your browser to authorize access to your Slack account.")
+	}
+	p.printInfo("Please ensure you have provided your clientID and clientSecret in the code or environment variables.")
+
+	// Check if using ngrok
+	localBase := fmt.Sprintf("localhost:%d", opts.port)
+	p.printInfo("For secure HTTPS connection, you have two options:")
+	p.printInfo("1. Use an ngrok URL (enter the https:// URL from ngrok)")
+	p.printInfo(fmt.Sprintf("2. Use localhost with HTTPS (enter 'https://%s')", localBase))
+	p.printInfo(fmt.Sprintf("3. Use localhost with HTTP (enter 'http://%s')", localBase))
+	p.printPrompt(fmt.Sprintf("Enter the callback URL base (or press Enter for https://%s)", localBase))
+	baseURL, err := p.readLine()
+	if err != nil {
+		return "", err
+	}
+
+	// Default callback URL
+	callbackURL := "https://" + localBase + "/callback"
+	if baseURL != "" {
+		// Make sure it doesn't include the /callback part
+		baseURL = strings.TrimSuffix(baseURL, "/")
+		baseURL = strings.TrimSuffix(baseURL, "/callback")
+		callbackURL = baseURL + "/callback"
+	}
+
+	p.printInfo("Using callback URL: " + callbackURL)
+
+	// Get clientID and clientSecret from env vars, the profile or prompt
+	cID := getEnvOrDefault("SLACK_CLIENT_ID", previous.ClientID)
+	cSecret := getEnvOrDefault("SLACK_CLIENT_SECRET", previous.ClientSecret)
+	if cID == "" {
+		cID = clientID
+	}
+	if cSecret == "" {
+		cSecret = clientSecret
+	}
+
+	if cID == "" {
+		if cID, err = p.getInput("Enter your Slack Client ID"); err != nil {
+			return "", err
+		}
+	}
+
+	if cSecret == "" {
+		if cSecret, err = p.getSecret("Enter your Slack Client Secret"); err != nil {
+			return "", err
+		}
+	}
+
+	req, err := newAuthRequest(cID, cSecret, callbackURL)
+	if err != nil {
+		return "", err
+	}
+
+	var config TokenConfig
+	if opts.headless {
+		config, err = authorizeHeadless(p, req)
+	} else {
+		config, err = authorizeInBrowser(p, req, opts.port)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Save the token along with the app credentials used to get it
+	config.ClientID = cID
+	config.ClientSecret = cSecret
+	if err := saveTokenConfig(p, profile, config); err != nil {
+		p.printInfo(fmt.Sprintf("Warning: Could not save token: %v", err))
+	}
+	rememberIdentity(config)
+	p.printSuccess(fmt.Sprintf("Authentication successful as %s in %s! 🎊", config.UserID, config.TeamID))
+	return config.AccessToken, nil
+}
+
+// authRequest is one attempt at the OAuth flow
+type authRequest struct {
+	clientID     string
+	clientSecret string
+	callbackURL  string
+	state        string // Random value checked on the callback against CSRF
+	verifier     string // PKCE code verifier, only ever sent to the token endpoint
+}
+
+// newAuthRequest creates the random state and PKCE verifier for a sign-in
+func newAuthRequest(clientID, clientSecret, callbackURL string) (authRequest, error) {
+	state, err := randomToken()
+	if err != nil {
+		return authRequest{}, err
+	}
+	verifier, err := randomToken()
+	if err != nil {
+		return authRequest{}, err
+	}
+
+	return authRequest{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		callbackURL:  callbackURL,
+		state:        state,
+		verifier:     verifier,
+	}, nil
+}
+
+// randomToken returns 32 bytes from crypto/rand, base64url encoded, which
+// also satisfies the PKCE verifier alphabet and length
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// authorizeURL builds the Slack authorize URL. Scopes go in user_scope so
+// Slack issues a user token (xoxp-) and posts really appear as the signed in
+// user; the PKCE challenge ties the code to this attempt's verifier.
+func (r authRequest) authorizeURL() string {
+	challenge := sha256.Sum256([]byte(r.verifier))
+	return slackAuthorizeURL + "?" + url.Values{
+		"client_id":             {r.clientID},
+		"user_scope":            {userScopes},
+		"state":                 {r.state},
+		"redirect_uri":          {r.callbackURL},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}.Encode()
+}
+
+// exchange trades the authorization code of this attempt for a token
+func (r authRequest) exchange(code string) (TokenConfig, error) {
+	return exchangeCode(r.clientID, r.clientSecret, code, r.callbackURL, r.verifier)
+}
+
+// authorizeInBrowser opens the authorize URL locally and waits for Slack to
+// redirect the browser to the local callback server
+func authorizeInBrowser(p *prompter, req authRequest, port int) (TokenConfig, error) {
+	// Start local server to receive the OAuth callback. It listens before the
+	// browser opens, so a busy port is reported straight away.
+	server, err := startCallbackServer(p, req, port)
+	if err != nil {
+		return TokenConfig{}, err
+	}
+	defer server.shutdown(p)
+
+	// Open browser to the authorization URL
+	authURL := req.authorizeURL()
+	err = openBrowser(authURL)
+	if err != nil {
+		p.printError(fmt.Sprintf("Could not open browser: %v", err))
+		p.printInfo(fmt.Sprintf("Please open this URL in your browser:\n%s", authURL))
+	}
+
+	p.printInfo("Waiting for authentication... 🔄")
+
+	// Wait for the token or error
+	select {
+	case result := <-server.results:
+		if result.err != nil {
+			return TokenConfig{}, fmt.Errorf("OAuth error: %v", result.err)
+		}
+		return result.config, nil
+	case <-time.After(callbackTimeout):
+		return TokenConfig{}, fmt.Errorf("authentication timed out")
+	}
+}
+
+// authorizeHeadless is for sessions without a local browser, such as SSH.
+// The user opens the authorize URL anywhere and pastes back the address the
+// browser was redirected to; the callback does not need to load.
+func authorizeHeadless(p *prompter, req authRequest) (TokenConfig, error) {
+	p.printInfo("Open this URL in a browser on any machine and approve access:")
+	fmt.Fprintln(p.writer, req.authorizeURL())
+	p.printInfo("Slack will then redirect to " + req.callbackURL + ". The page will not load, which is fine.")
+
+	pasted, err := p.getInput("Paste the full address from the browser's address bar (or just the code)")
+	if err != nil {
+		return TokenConfig{}, err
+	}
+
+	code, err := parseCallback(pasted, req.state)
+	if err != nil {
+		return TokenConfig{}, err
+	}
+
+	p.printInfo("Exchanging the code for a token... 🔄")
+	return req.exchange(code)
+}
+
+// parseCallback extracts the authorization code from a pasted redirect URL,
+// checking its state, or accepts a bare code as is
+func parseCallback(pasted, state string) (string, error) {
+	pasted = strings.TrimSpace(pasted)
+	if pasted == "" {
+		return "", fmt.Errorf("nothing was pasted")
+	}
+
+	if !strings.Contains(pasted, "=") {
+		// A bare code has no state to check; the user copied it from the
+		// browser they just approved in
+		return pasted, nil
+	}
+
+	query := pasted
+	if i := strings.Index(pasted, "?"); i >= 0 {
+		query = pasted[i+1:]
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return "", fmt.Errorf("could not read the pasted address: %v", err)
+	}
+
+	if values.Get("error") != "" {
+		return "", fmt.Errorf("authorization error: %s", values.Get("error"))
+	}
+	if values.Get("state") != state {
+		return "", fmt.Errorf("invalid state parameter, make sure you pasted the address from this login attempt")
+	}
+	if values.Get("code") == "" {
+		return "", fmt.Errorf("no code found in the pasted address")
+	}
+
+	return values.Get("code"), nil
+}
+
+// exchangeCode trades an authorization code for the user's token through
+// oauth.v2.access
+func exchangeCode(clientID, clientSecret, code, callbackURL, verifier string) (TokenConfig, error) {
+	return requestToken(url.Values{
+		"client_id":     {clientID},
+		"client_secret": {clientSecret},
+		"code":          {code},
+		"redirect_uri":  {callbackURL},
+		"code_verifier": {verifier},
+	})
+}
+
+// refreshAccessToken trades a rotating token's refresh token for a new
+// access and refresh token pair. Everything not in the response is kept.
+func refreshAccessToken(config TokenConfig) (TokenConfig, error) {
+	refreshed, err := requestToken(url.Values{
+		"client_id":     {getEnvOrDefault("SLACK_CLIENT_ID", config.ClientID)},
+		"client_secret": {getEnvOrDefault("SLACK_CLIENT_SECRET", config.ClientSecret)},
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {config.RefreshToken},
+	})
+	if err != nil {
+		return config, err
+	}
+
+	config.AccessToken = refreshed.AccessToken
+	config.Expiration = refreshed.Expiration
+	if refreshed.RefreshToken != "" {
+		config.RefreshToken = refreshed.RefreshToken
+	}
+	if refreshed.UserID != "" {
+		config.UserID = refreshed.UserID
+	}
+	if refreshed.TeamID != "" {
+		config.TeamID = refreshed.TeamID
+	}
+	if refreshed.Scopes != "" {
+		config.Scopes = refreshed.Scopes
+	}
+	return config, nil
+}
+
+// requestToken calls oauth.v2.access and reads the user token out of the
+// response, including the refresh token and expiry of rotating tokens
+func requestToken(values url.Values) (TokenConfig, error) {
+	var config TokenConfig
+
+	tokenResp, err := http.PostForm(slackTokenURL, values)
+	if err != nil {
+		return config, fmt.Errorf("token exchange error: %v", err)
+	}
+	defer tokenResp.Body.Close()
+
+	// Parse token response. With user_scope the user token is nested under
+	// authed_user; a top level access_token is only used when it is marked
+	// as a user token, as in some refresh responses, and is otherwise a bot
+	// token.
+	type tokenFields struct {
+		Scope        string `json:"scope"`
+		AccessToken  string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+	}
+	var tokenData struct {
+		Ok    bool   `json:"ok"`
+		Error string `json:"error,omitempty"`
+		tokenFields
+		AuthedUser struct {
+			ID string `json:"id"`
+			tokenFields
+		} `json:"authed_user"`
+		Team struct {
+			ID string `json:"id"`
+		} `json:"team"`
+	}
+
+	body, err := io.ReadAll(tokenResp.Body)
+	if err != nil {
+		return config, fmt.Errorf("error reading response: %v", err)
+	}
+
+	if err := json.Unmarshal(body, &tokenData); err != nil {
+		return config, fmt.Errorf("error parsing token response: %v", err)
+	}
+
+	if !tokenData.Ok {
+		return config, fmt.Errorf("Slack API error: %s", tokenData.Error)
+	}
+
+	token := tokenData.AuthedUser.tokenFields
+	if token.AccessToken == "" && tokenData.TokenType == "user" {
+		token = tokenData.tokenFields
+	}
+	if token.AccessToken == "" {
+		return config, fmt.Errorf("Slack did not return a user token; add %s under \"User Token Scopes\" of your app", userScopes)
+	}
+
+	config.AccessToken = token.AccessToken
+	config.RefreshToken = token.RefreshToken
+	config.UserID = tokenData.AuthedUser.ID
+	config.TeamID = tokenData.Team.ID
+	config.Scopes = token.Scope
+	if token.ExpiresIn > 0 {
+		config.Expiration = time.Now().Unix() + token.ExpiresIn
+	} else {
+		config.Expiration = 0 // No expiration without token rotation
+	}
+	return config, nil
+}
+
+// refreshSavedToken refreshes a profile's rotating token and saves the new
+// pair right away, since the old refresh token stops working once used
+func refreshSavedToken(p *prompter, profile string, config TokenConfig) (string, error) {
+	p.printInfo("Refreshing your Slack token 🔄")
+
+	refreshed, err := refreshAccessToken(config)
+	if err != nil {
+		return "", fmt.Errorf("refreshing token: %v", err)
+	}
+
+	if err := saveTokenConfig(p, profile, refreshed); err != nil {
+		return "", fmt.Errorf("saving refreshed token: %v", err)
+	}
+	rememberIdentity(refreshed)
+	return refreshed.AccessToken, nil
+}
+
+// newSlackClient returns an API client for a profile's token that survives
+// the token expiring during the run
+func newSlackClient(p *prompter, profile, token string) *slack.Client {
+	return slack.New(token, slack.OptionAPIURL(slackAPIURL), slack.OptionHTTPClient(&refreshingClient{p: p, profile: profile, initial: token, token: token}))
+}
+
+// refreshingClient sends the Slack client's requests. A call failing with
+// token_expired refreshes the profile's rotating token and is made again;
+// later calls then carry the new token in place of the one the client holds.
+type refreshingClient struct {
+	p       *prompter
+	profile string
+	initial string // The token the slack client was created with
+	token   string // The token to send, once refreshed
+}
+
+// Do sends a request, retrying it once after refreshing an expired token
+func (c *refreshingClient) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+
+	resp, err := c.send(req, body)
+	if err != nil || !strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		return resp, err
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(data, &result) == nil && result.Error == "token_expired" {
+		refreshed, refreshErr := c.refresh()
+		if refreshErr != nil {
+			return nil, refreshErr
+		}
+		if refreshed {
+			return c.send(req, body)
+		}
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	return resp, nil
+}
+
+// send makes one attempt at a request with the current token, which goes
+// in the Authorization header or, for some methods, the form
+func (c *refreshingClient) send(req *http.Request, body []byte) (*http.Response, error) {
+	attempt := req.Clone(req.Context())
+	if c.token != c.initial {
+		if attempt.Header.Get("Authorization") == "Bearer "+c.initial {
+			attempt.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		if strings.HasPrefix(attempt.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+			if values, err := url.ParseQuery(string(body)); err == nil && values.Get("token") == c.initial {
+				values.Set("token", c.token)
+				body = []byte(values.Encode())
+			}
+		}
+	}
+	if body != nil {
+		attempt.Body = io.NopCloser(bytes.NewReader(body))
+		attempt.ContentLength = int64(len(body))
+	}
+	return http.DefaultClient.Do(attempt)
+}
+
+// refresh refreshes the profile's token after Slack reported it expired.
+// It reports false when there is nothing to refresh, e.g. for SLACK_TOKEN.
+func (c *refreshingClient) refresh() (bool, error) {
+	if os.Getenv("SLACK_TOKEN") != "" {
+		return false, nil
+	}
+	config, err := readTokenConfig(c.p, c.profile)
+	if err != nil || config.RefreshToken == "" {
+		return false, nil
+	}
+	token, err := refreshSavedToken(c.p, c.profile, config)
+	if err != nil {
+		return false, err
+	}
+	c.token = token
+	return true, nil
+}
+
+// openBrowser opens the default browser to the specified URL
+func openBrowser(url string) error {
+	var err error
+
+	switch runtime.GOOS {
+	case "linux":
+		err = exec.Command("xdg-open", url).Start()
+	case "windows":
+		err = exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	case "darwin":
+		err = exec.Command("open", url).Start()
+	default:
+		err = fmt.Errorf("unsupported platform")
+	}
+
+	return err
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// pickerRows is how many matches the picker shows at once
+const pickerRows = 10
+
+// pickerWidth keeps lines from wrapping, which would break redrawing
+const pickerWidth = 76
+
+// escapeTimeout is how long to wait after Esc for the rest of a sequence
+// before taking it as the Esc key itself
+const escapeTimeout = 200 * time.Millisecond
+
+// errCancelled is returned when the picker or the line editor is left with
+// Esc or Ctrl-C
+var errCancelled = errors.New("cancelled")
+
+// pickerItem is one choice offered by the picker
+type pickerItem struct {
+	label  string  // Shown and matched against what is typed
+	detail string  // Shown after the label
+	rank   float64 // Higher comes first; ties keep their original order
+}
+
+// fuzzyScore matches query against label as a case-insensitive subsequence.
+// Runs of consecutive characters and matches at the start of a word score
+// higher, so "tst" prefers "team-standup" over "the-last-test".
+func fuzzyScore(query, label string) (int, bool) {
+	query = strings.ToLower(query)
+	label = strings.ToLower(label)
+	if query == "" {
+		return 0, true
+	}
+
+	score := 0
+	qi := 0
+	queryRunes := []rune(query)
+	prevMatched := false
+	prev := ' '
+	for _, r := range label {
+		if qi < len(queryRunes) && r == queryRunes[qi] {
+			score++
+			if prevMatched {
+				score += 5
+			}
+			if !unicode.IsLetter(prev) && !unicode.IsDigit(prev) {
+				score += 3
+			}
+			qi++
+			prevMatched = true
+		} else {
+			prevMatched = false
+		}
+		prev = r
+	}
+	if qi < len(queryRunes) {
+		return 0, false
+	}
+
+	// Of two equally good matches, the shorter label is the closer one
+	return score*100 - utf8.RuneCountInString(label), true
+}
+
+// filterItems returns the indexes of the items matching query, best first
+func filterItems(items []pickerItem, query string) []int {
+	type match struct {
+		index int
+		score int
+	}
+
+	var matches []match
+	for i, item := range items {
+		if score, ok := fuzzyScore(query, item.label); ok {
+			matches = append(matches, match{i, score})
+		}
+	}
+	sort.SliceStable(matches, func(a, b int) bool {
+		if matches[a].score != matches[b].score {
+			return matches[a].score > matches[b].score
+		}
+		return items[matches[a].index].rank > items[matches[b].index].rank
+	})
+
+	indexes := make([]int, len(matches))
+	for i, m := range matches {
+		indexes[i] = m.index
+	}
+	return indexes
+}
+
+// pick lets the user choose among items by typing to filter and moving with
+// the arrow keys, and returns the index of the chosen item. It needs a
+// terminal; check interactive() first and fall back to a numbered menu.
+func (p *prompter) pick(title string, items []pickerItem) (int, error) {
+	state, err := enableRawMode(p.inFd)
+	if err != nil {
+		return -1, err
+	}
+	defer restoreTerminal(p.inFd, state)
+
+	// Sort once by rank, so an empty query lists the likeliest items first
+	sorted := make([]pickerItem, len(items))
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return items[order[a]].rank > items[order[b]].rank })
+	for i, index := range order {
+		sorted[i] = items[index]
+	}
+
+	p.printInfo(title)
+
+	var query []rune
+	selected, top, drawn := 0, 0, 0
+	for {
+		matches := filterItems(sorted, string(query))
+		if selected >= len(matches) {
+			selected = len(matches) - 1
+		}
+		if selected < 0 {
+			selected = 0
+		}
+		if selected < top {
+			top = selected
+		}
+		if selected >= top+pickerRows {
+			top = selected - pickerRows + 1
+		}
+
+		drawn = p.drawPicker(drawn, sorted, matches, selected, top, string(query))
+
+		key, err := p.readKey()
+		if err != nil {
+			p.clearPicker(drawn)
+			return -1, err
+		}
+
+		switch key {
+		case keyEnter:
+			if len(matches) == 0 {
+				continue
+			}
+			p.clearPicker(drawn)
+			chosen := sorted[matches[selected]]
+			p.printSuccess(fmt.Sprintf("%s %s", chosen.label, chosen.detail))
+			return order[matches[selected]], nil
+		case keyEscape, keyInterrupt:
+			p.clearPicker(drawn)
+			return -1, errCancelled
+		case keyUp:
+			selected--
+		case keyDown:
+			selected++
+		case keyBackspace:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				selected, top = 0, 0
+			}
+		case keyClearLine:
+			query = nil
+			selected, top = 0, 0
+		default:
+			if unicode.IsPrint(key) {
+				query = append(query, key)
+				selected, top = 0, 0
+			}
+		}
+	}
+}
+
+// drawPicker redraws the list over the previous drawing and returns how
+// many lines it now takes up. The query line comes last, so the cursor
+// stays where typing happens.
+func (p *prompter) drawPicker(drawn int, items []pickerItem, matches []int, selected, top int, query string) int {
+	p.clearPicker(drawn)
+
+	lines := 0
+	for row := top; row < len(matches) && row < top+pickerRows; row++ {
+		item := items[matches[row]]
+		line := truncate(item.label+"  "+item.detail, pickerWidth-2)
+		if row == selected {
+			if useColors {
+				fmt.Fprintf(p.writer, "%s%s▶ %s%s\n", colorGreen, colorBold, line, colorReset)
+			} else {
+				fmt.Fprintf(p.writer, "▶ %s\n", line)
+			}
+		} else {
+			fmt.Fprintf(p.writer, "  %s\n", line)
+		}
+		lines++
+	}
+	if len(matches) == 0 {
+		fmt.Fprintln(p.writer, "  (no matches)")
+		lines++
+	}
+
+	fmt.Fprintf(p.writer, "  %d of %d · type to filter, ↑/↓ to move, Enter to choose, Esc to cancel\n", len(matches), len(items))
+	if useColors {
+		fmt.Fprintf(p.writer, "%s%s👉 > %s%s", colorYellow, colorBold, colorReset, query)
+	} else {
+		fmt.Fprintf(p.writer, "👉 > %s", query)
+	}
+	return lines + 2
+}
+
+// clearPicker erases a drawing of the given number of lines
+func (p *prompter) clearPicker(drawn int) {
+	if drawn == 0 {
+		return
+	}
+	if drawn > 1 {
+		fmt.Fprintf(p.writer, "\033[%dA", drawn-1)
+	}
+	fmt.Fprint(p.writer, "\r\033[J")
+}
+
+// truncate shortens s to at most n characters
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
+
+// Keys readKey reports besides plain characters. Most are the control
+// characters readline uses, which the matching escape sequences map to;
+// the rest are private use runes, so none can clash with typed text.
+const (
+	keyHome       = 0x01 // Ctrl-A, also Home
+	keyLeft       = 0x02 // Ctrl-B, also the left arrow
+	keyInterrupt  = 0x03 // Ctrl-C
+	keyDelete     = 0x04 // Ctrl-D, also Delete
+	keyEnd        = 0x05 // Ctrl-E, also End
+	keyRight      = 0x06 // Ctrl-F, also the right arrow
+	keyKillEnd    = 0x0b // Ctrl-K
+	keyEnter      = '\r'
+	keyDown       = 0x0e // Ctrl-N, also the down arrow
+	keyUp         = 0x10 // Ctrl-P, also the up arrow
+	keyClearLine  = 0x15 // Ctrl-U
+	keyDeleteWord = 0x17 // Ctrl-W, also Alt-Backspace
+	keyEscape     = 0x1b
+	keyBackspace  = 0x7f
+
+	keyWordLeft   = 0xe000 // Alt-B or Ctrl-Left
+	keyWordRight  = 0xe001 // Alt-F or Ctrl-Right
+	keyPasteStart = 0xe002 // Start of a bracketed paste
+	keyPasteEnd   = 0xe003 // End of a bracketed paste
+)
+
+// readKey reads one key press in raw mode, turning escape sequences for
+// the arrow and editing keys into the keys above
+func (p *prompter) readKey() (rune, error) {
+	r, _, err := p.reader.ReadRune()
+	if err != nil {
+		return 0, errNoInput
+	}
+
+	switch r {
+	case '\n':
+		return keyEnter, nil
+	case 0x08: // Ctrl-H
+		return keyBackspace, nil
+	case keyEscape:
+		// A lone Esc has nothing behind it. A sequence usually arrives all at
+		// once, but over a slow link its rest may come a moment later.
+		if p.reader.Buffered() == 0 && !p.waitForInput(escapeTimeout) {
+			return keyEscape, nil
+		}
+		next, _, err := p.reader.ReadRune()
+		if err != nil {
+			return keyEscape, nil
+		}
+		switch next {
+		case '[', 'O':
+			return p.readEscapeSequence()
+		case 'b':
+			return keyWordLeft, nil
+		case 'f':
+			return keyWordRight, nil
+		case keyBackspace, 0x08:
+			return keyDeleteWord, nil
+		}
+		return keyEscape, nil
+	}
+	return r, nil
+}
+
+// waitForInput reports whether more input arrives within timeout
+func (p *prompter) waitForInput(timeout time.Duration) bool {
+	if p.inFd < 0 || setReadTimeout(p.inFd, timeout) != nil {
+		return false
+	}
+	defer setReadTimeout(p.inFd, 0)
+
+	// A read that times out comes back empty, which the reader sees as EOF
+	_, err := p.reader.Peek(1)
+	return err == nil
+}
+
+// readEscapeSequence reads the rest of a sequence after "ESC [" or "ESC O":
+// parameters such as "1;5" followed by a final letter or "~". Sequences
+// that are not handled come back as 0.
+func (p *prompter) readEscapeSequence() (rune, error) {
+	var params []rune
+	for {
+		r, _, err := p.reader.ReadRune()
+		if err != nil {
+			return keyEscape, nil
+		}
+		if r < 0x40 || r > 0x7e {
+			params = append(params, r)
+			continue
+		}
+
+		// A modifier, e.g. "1;5C" for Ctrl-Right, moves by word
+		modified := strings.Contains(string(params), ";")
+		switch r {
+		case 'A':
+			return keyUp, nil
+		case 'B':
+			return keyDown, nil
+		case 'C':
+			if modified {
+				return keyWordRight, nil
+			}
+			return keyRight, nil
+		case 'D':
+			if modified {
+				return keyWordLeft, nil
+			}
+			return keyLeft, nil
+		case 'H':
+			return keyHome, nil
+		case 'F':
+			return keyEnd, nil
+		case '~':
+			switch string(params) {
+			case "1", "7":
+				return keyHome, nil
+			case "4", "8":
+				return keyEnd, nil
+			case "3":
+				return keyDelete, nil
+			case "200":
+				return keyPasteStart, nil
+			case "201":
+				return keyPasteEnd, nil
+			}
+		}
+		return 0, nil
+	}
+}
@@ -0,0 +1,456 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// runPost collects a standup and posts it to Slack, prompting only for
+// whatever was not supplied on the command line
+func runPost(p *prompter, args []string) error {
+	settings, err := loadSettings()
+	if err != nil {
+		return fmt.Errorf("loading settings: %v", err)
+	}
+
+	opts, err := parsePostFlags(args, settings.Questions)
+	if err != nil {
+		return err
+	}
+
+	if err := checkProfileExists(p, opts.profile); err != nil {
+		return err
+	}
+
+	p.printHeader("Slack Standup Updater 🚀")
+
+	// Set up user authentication
+	token, err := getUserToken(p, opts.profile, opts.login)
+	if err != nil {
+		return fmt.Errorf("getting user token: %v", err)
+	}
+
+	// Initialize Slack API client (needed for DM channel lookup); it refreshes
+	// a rotating token that expires while the standup is written
+	api := newSlackClient(p, opts.profile, token)
+	dir := newDirectory(p, api)
+
+	channelID, threadTS := opts.channel, opts.thread
+	destination := opts.to
+	if channelID != "" {
+		if channelID, err = dir.channelID(channelID); err != nil {
+			return err
+		}
+	} else if destination != "" {
+		dest, err := lookupDestination(settings.Destinations, destination)
+		if err != nil {
+			return err
+		}
+		if channelID, threadTS, err = resolveDestination(p, dir, dest); err != nil {
+			return err
+		}
+		p.printSuccess(fmt.Sprintf("Posting to %s (%s)", destination, dest.describe()))
+	} else if opts.link != "" {
+		link, err := parseSlackLink(opts.link)
+		if err != nil {
+			return fmt.Errorf("parsing Slack link: %v", err)
+		}
+		channelID, threadTS = link.ChannelID, link.ThreadTS
+		p.printSuccess(fmt.Sprintf("Successfully parsed link. Channel ID: %s, Thread TS: %s", channelID, threadTS))
+	}
+
+	if channelID == "" {
+		destination, channelID, threadTS, err = selectDestination(p, dir, settings.Destinations)
+		if err != nil {
+			return err
+		}
+	} else if threadTS == autoThread {
+		if threadTS, err = discoverThread(p, api, channelID, ThreadFinder{}); err != nil {
+			return err
+		}
+	}
+
+	// Get answers from flags, asking for anything that is missing
+	answers := make(map[string]string)
+
+	// Pick up where a saved draft left off, unless answers were given
+	if draft, err := loadDraft(); err == nil && draft != nil && p.inFd >= 0 && !opts.hasAnyAnswer() {
+		p.printInfo(fmt.Sprintf("You have a draft saved %s. Continue with it? (y/n)", time.Unix(draft.Saved, 0).Format("Mon Jan 2 15:04")))
+		answer, err := p.choose()
+		if err != nil {
+			return err
+		}
+		if strings.ToLower(answer) == "y" || strings.ToLower(answer) == "yes" {
+			answers = draft.Answers
+		}
+	}
+
+	useEditor := (opts.editor || settings.UseEditor) && !opts.hasAllAnswers()
+	if opts.editor && p.inFd < 0 {
+		return fmt.Errorf("--editor needs a terminal")
+	}
+
+	// The plan from last time comes back as a checklist of what got done
+	prefill := make(map[string]string)
+	if p.inFd >= 0 {
+		answered := make(map[string]bool)
+		for _, q := range settings.Questions {
+			_, drafted := answers[q.ID]
+			answered[q.ID] = drafted || opts.answers[q.ID].set
+		}
+		profile := profileName(p, opts.profile)
+		if prefill, err = carryOver(p, api, settings.Questions, answered, profile, channelID, threadTS, !useEditor); err != nil {
+			return err
+		}
+		if err := suggestBullets(p, settings, profile, answered, prefill, !useEditor); err != nil {
+			return err
+		}
+	}
+
+	if useEditor && p.inFd >= 0 {
+		// Everything known so far goes into the document to be edited
+		for _, q := range settings.Questions {
+			if flag := opts.answers[q.ID]; flag.set {
+				answers[q.ID] = flag.String()
+			} else if _, drafted := answers[q.ID]; !drafted && prefill[q.ID] != "" {
+				answers[q.ID] = prefill[q.ID]
+			}
+		}
+		if answers, err = composeInEditor(p, settings.Questions, answers); err != nil {
+			return err
+		}
+	} else {
+		p.printHeader("Standup Questions 📋")
+		if !opts.hasAllAnswers() {
+			p.printInfo("Enter multiple bullet points per question. Press Enter twice when done with a question.")
+			p.printDivider()
+		}
+
+		drafted := make(map[string]bool)
+		for id := range answers {
+			drafted[id] = true
+		}
+
+		// asked holds the questions answered here, to go back through
+		var asked []int
+		for i := 0; i < len(settings.Questions); i++ {
+			q := settings.Questions[i]
+			if flag := opts.answers[q.ID]; flag.set {
+				if len(flag.lines) == 0 && q.Required {
+					return fmt.Errorf("%q needs an answer", q.Prompt)
+				}
+				answers[q.ID] = flag.String()
+				continue
+			}
+			if drafted[q.ID] {
+				continue
+			}
+
+			current := answers[q.ID]
+			if current == "" {
+				current = prefill[q.ID]
+			}
+			answer, err := p.answerQuestion(q, current, len(asked) > 0)
+			if err == errGoBack {
+				i = asked[len(asked)-1] - 1
+				asked = asked[:len(asked)-1]
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			answers[q.ID] = answer
+			asked = append(asked, i)
+		}
+	}
+
+	// Format message, and let the user look it over unless this is a script:
+	// flags that say everything, like those in a Makefile, skip the review
+	// too unless it is asked for
+	message := formatStandupMessage(settings.Questions, answers)
+	scripted := opts.hasDestination() && opts.hasAllAnswers() && !opts.review
+	for p.inFd >= 0 && !opts.yes && !scripted {
+		previewStandup(p, dir, channelID, threadTS, message)
+
+		action, err := chooseReviewAction(p)
+		if err != nil {
+			return err
+		}
+
+		switch action {
+		case reviewPost:
+			// Leave the review and post below
+		case reviewEdit:
+			if useEditor {
+				edited, err := composeInEditor(p, settings.Questions, answers)
+				if err != nil {
+					p.printError(err.Error())
+					continue
+				}
+				answers = edited
+			} else if err := editSection(p, settings.Questions, answers); err != nil {
+				return err
+			}
+			message = formatStandupMessage(settings.Questions, answers)
+			continue
+		case reviewDestination:
+			destination, channelID, threadTS, err = selectDestination(p, dir, settings.Destinations)
+			if err != nil {
+				return err
+			}
+			continue
+		case reviewDraft:
+			if err := saveDraft(Draft{Answers: answers, Saved: time.Now().Unix()}); err != nil {
+				return fmt.Errorf("saving draft: %v", err)
+			}
+			p.printSuccess("Draft saved, it will be offered the next time you post")
+			return nil
+		case reviewCancel:
+			p.printInfo("Cancelled, nothing was posted.")
+			return nil
+		}
+		break
+	}
+
+	p.printHeader("Posting to Slack 💬")
+	p.printInfo("Sending your standup message...")
+
+	messageTS, err := postStandup(p, api, channelID, threadTS, message)
+	if err != nil {
+		return fmt.Errorf("posting message: %v", err)
+	}
+
+	entry := newHistoryEntry(settings.Questions, answers, message, time.Now())
+	entry.Profile = profileName(p, opts.profile)
+	if info, err := identity(api); err == nil {
+		entry.UserID, entry.TeamID = info.UserID, info.TeamID
+	}
+	entry.Destination = destination
+	entry.ChannelID, entry.ThreadTS, entry.MessageTS = channelID, threadTS, messageTS
+	recordStandup(p, api, dir, entry)
+
+	if err := clearDraft(); err != nil {
+		p.printInfo(fmt.Sprintf("Warning: Could not remove the saved draft: %v", err))
+	}
+	rememberDestination(p, destination, channelID)
+
+	p.printDivider()
+	p.printSuccess("Standup posted successfully! 🎉")
+	return nil
+}
+
+// selectDestination asks where to post: the picker in a terminal, else the
+// numbered menu of saved destinations or the original options
+func selectDestination(p *prompter, dir *directory, destinations map[string]Destination) (string, string, string, error) {
+	if p.interactive() {
+		return pickDestination(p, dir, destinations)
+	}
+	if len(destinations) > 0 {
+		return chooseDestination(p, dir, destinations, loadState().LastDestination)
+	}
+	channelID, threadTS, err := promptDestination(p, dir)
+	return "", channelID, threadTS, err
+}
+
+// postStandup sends the formatted message to a channel, thread or DM and
+// returns the timestamp of the posted message
+func postStandup(p *prompter, api *slack.Client, channelID, threadTS, message string) (string, error) {
+	options := []slack.MsgOption{
+		slack.MsgOptionText(message, false),
+		slack.MsgOptionAsUser(true), // This posts as the authenticated user
+	}
+
+	if threadTS != "" {
+		// Posting to a thread
+		p.printInfo("Posting to thread in channel...")
+		options = append(options, slack.MsgOptionTS(threadTS))
+	} else {
+		// Posting to a DM or channel (not as a thread reply)
+		p.printInfo("Posting direct message...")
+	}
+
+	_, messageTS, err := api.PostMessage(channelID, options...)
+	return messageTS, err
+}
+
+// promptDestination asks where the standup should be posted and returns the
+// channel ID and, for thread replies, the thread timestamp
+func promptDestination(p *prompter, dir *directory) (string, string, error) {
+	p.printHeader("Thread Selection 🧵")
+	p.printInfo("Where do you want to post your standup?")
+	p.printInfo("1. Reply to a thread in a channel (y)")
+	p.printInfo("2. Message yourself directly (m)")
+	p.printInfo("3. Post to Slackbot (s) - most reliable way to message yourself")
+	p.printInfo("4. Message any user by ID (u) - works with all token types")
+
+	answer, err := p.choose()
+	if err != nil {
+		return "", "", err
+	}
+	return followDestinationChoice(p, dir, answer)
+}
+
+// isDestinationShortcut reports whether an answer is one of the y/m/s/u
+// choices of promptDestination
+func isDestinationShortcut(answer string) bool {
+	switch strings.ToLower(answer) {
+	case "y", "yes", "channel", "m", "myself", "me", "s", "slackbot", "u", "user":
+		return true
+	}
+	return false
+}
+
+// followDestinationChoice asks for whatever else the chosen kind of
+// destination needs, and returns its channel ID and thread timestamp
+func followDestinationChoice(p *prompter, dir *directory, answer string) (string, string, error) {
+	var channelID, threadTS string
+	var err error
+	api := dir.api
+
+	if strings.ToLower(answer) == "u" || strings.ToLower(answer) == "user" {
+		p.printInfo("Sending a direct message to a specific user 👥")
+
+		// Get the user to message
+		userID, err := askUser(p, dir)
+		if err != nil {
+			return "", "", err
+		}
+
+		// Open a conversation with this user
+		channel, _, _, err := api.OpenConversation(&slack.OpenConversationParameters{
+			Users: []string{userID},
+		})
+
+		if err != nil {
+			p.printError(fmt.Sprintf("Opening conversation with user: %v", err))
+			p.printInfo("Falling back to manual channel ID entry...")
+			if channelID, err = p.getInput("Enter the DM channel ID for this user (starts with D)"); err != nil {
+				return "", "", err
+			}
+		} else {
+			channelID = channel.ID
+			p.printSuccess(fmt.Sprintf("Found DM channel with user %s: %s", userID, channelID))
+		}
+
+		threadTS = "" // No thread, just post to the DM
+	} else if strings.ToLower(answer) == "s" || strings.ToLower(answer) == "slackbot" {
+		p.printInfo("Sending to Slackbot 🤖")
+
+		// The Slackbot DM is the IM whose other member is Slackbot
+		channelID, err = findIM(api, slackbotUserID)
+		if err != nil {
+			p.printError(fmt.Sprintf("Couldn't find your Slackbot channel: %v", err))
+			p.printInfo("To find your Slackbot channel ID:")
+			p.printInfo("1. Open Slack in a browser")
+			p.printInfo("2. Click on Slackbot in the sidebar")
+			p.printInfo("3. The URL will contain your Slackbot channel ID, like: /messages/DXXXXXXXX")
+			if channelID, err = p.getInput("Enter your Slackbot channel ID (starts with D)"); err != nil {
+				return "", "", err
+			}
+		} else {
+			p.printSuccess(fmt.Sprintf("Found Slackbot channel: %s", channelID))
+		}
+
+		threadTS = "" // No thread, just post to Slackbot
+	} else if strings.ToLower(answer) == "m" || strings.ToLower(answer) == "myself" || strings.ToLower(answer) == "me" {
+		p.printInfo("Sending to your Slack DM (yourself) 👤")
+
+		// Get the user's own identity
+		userInfo, err := identity(api)
+		if err != nil {
+			return "", "", fmt.Errorf("getting user info: %v", err)
+		}
+
+		channelID, err = selfDM(api, userInfo.UserID)
+		if err != nil {
+			p.printError(fmt.Sprintf("Couldn't find the DM with yourself: %v", err))
+			if channelID, err = p.getInput("Enter the channel ID of your DM with yourself (starts with D)"); err != nil {
+				return "", "", err
+			}
+		}
+
+		threadTS = "" // No thread, just post to the DM channel
+
+		p.printSuccess(fmt.Sprintf("Will post to DM. User ID: %s, Channel ID: %s", userInfo.UserID, channelID))
+	} else if strings.ToLower(answer) == "y" || strings.ToLower(answer) == "yes" || strings.ToLower(answer) == "channel" {
+		p.printInfo("Do you have a Slack message link? (y/n)")
+		answer, err := p.choose()
+		if err != nil {
+			return "", "", err
+		}
+
+		if strings.ToLower(answer) == "y" || strings.ToLower(answer) == "yes" {
+			p.printInfo("Enter the Slack message link (e.g., ******.slack.com/archives/C048ECCB75H/p1743724813501239):")
+			link, err := p.choose()
+			if err != nil {
+				return "", "", err
+			}
+
+			parsed, err := parseSlackLink(link)
+			if err != nil {
+				p.printError(fmt.Sprintf("Parsing Slack link: %v", err))
+				p.printInfo("Falling back to manual entry...")
+				if channelID, err = askChannel(p, dir); err != nil {
+					return "", "", err
+				}
+				if threadTS, err = p.getInput("Enter the thread timestamp"); err != nil {
+					return "", "", err
+				}
+			} else {
+				channelID, threadTS = parsed.ChannelID, parsed.ThreadTS
+				p.printSuccess(fmt.Sprintf("Successfully parsed link. Channel ID: %s, Thread TS: %s", channelID, threadTS))
+			}
+		} else {
+			if channelID, err = askChannel(p, dir); err != nil {
+				return "", "", err
+			}
+			if threadTS, err = p.getInput("Enter the thread timestamp"); err != nil {
+				return "", "", err
+			}
+		}
+	} else {
+		// Default to asking for channel info if the input wasn't recognized
+		p.printInfo("Defaulting to channel thread...")
+		if channelID, err = askChannel(p, dir); err != nil {
+			return "", "", err
+		}
+		if threadTS, err = p.getInput("Enter the thread timestamp"); err != nil {
+			return "", "", err
+		}
+	}
+
+	return channelID, threadTS, nil
+}
+
+// askChannel asks for a channel by name or ID until it can be found
+func askChannel(p *prompter, dir *directory) (string, error) {
+	for {
+		ref, err := p.getInput("Enter the channel (#name or ID)")
+		if err != nil {
+			return "", err
+		}
+		channelID, err := dir.channelID(ref)
+		if err == nil {
+			return channelID, nil
+		}
+		p.printError(err.Error())
+	}
+}
+
+// askUser asks for a user by handle, name, email or ID until it can be found
+func askUser(p *prompter, dir *directory) (string, error) {
+	for {
+		ref, err := p.getInput("Enter the user to message (@handle, name, email or ID)")
+		if err != nil {
+			return "", err
+		}
+		userID, err := dir.userID(ref)
+		if err == nil {
+			return userID, nil
+		}
+		p.printError(err.Error())
+	}
+}
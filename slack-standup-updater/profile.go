@@ -0,0 +1,429 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// defaultProfile is used when no profile is named and none is marked default
+const defaultProfile = "default"
+
+// validProfileName keeps profile names usable as flags and file names
+var validProfileName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// TokenConfig holds the credentials of one Slack workspace
+type TokenConfig struct {
+	AccessToken  string `json:"access_token"`
+	UserID       string `json:"user_id"`
+	TeamID       string `json:"team_id"`
+	Expiration   int64  `json:"expiration"` // Unix time, 0 for tokens that do not expire
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	Scopes       string `json:"scopes,omitempty"`
+	TokenCommand string `json:"token_command,omitempty"` // Run on demand; its token is never stored
+}
+
+// tokenStore is the content of token.json: named credential profiles plus
+// the name of the one used when --profile is not given
+type tokenStore struct {
+	Default  string                 `json:"default,omitempty"`
+	Profiles map[string]TokenConfig `json:"profiles"`
+
+	key *storeKey // Set when the file was encrypted, to seal it again on save
+}
+
+// resolve returns the profile name to use for an optional --profile value
+func (s tokenStore) resolve(name string) string {
+	if name != "" {
+		return name
+	}
+	if s.Default != "" {
+		return s.Default
+	}
+	// A lone profile needs no default to be picked
+	if len(s.Profiles) == 1 {
+		for name := range s.Profiles {
+			return name
+		}
+	}
+	return defaultProfile
+}
+
+// loadTokenStore reads token.json, unlocking it first if it is encrypted. A
+// file from before profiles existed holds a single TokenConfig and is read as
+// the "default" profile. A plaintext file is encrypted here if
+// encrypt_credentials has been turned on since it was written.
+func loadTokenStore(p *prompter) (tokenStore, error) {
+	var store tokenStore
+
+	path, err := configPath(configFile)
+	if err != nil {
+		return store, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		store.Profiles = make(map[string]TokenConfig)
+		return store, nil
+	}
+	if err != nil {
+		return store, err
+	}
+
+	var envelope storeEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return store, fmt.Errorf("parsing %s: %v", path, err)
+	}
+	if envelope.Encrypted != nil {
+		plaintext, key, err := unlockStore(p, envelope.Encrypted)
+		if err != nil {
+			return store, err
+		}
+		data, store.key = plaintext, &key
+	}
+
+	if err := json.Unmarshal(data, &store); err != nil {
+		return store, fmt.Errorf("parsing %s: %v", path, err)
+	}
+
+	if store.Profiles == nil {
+		store.Profiles = make(map[string]TokenConfig)
+
+		var legacy TokenConfig
+		if err := json.Unmarshal(data, &legacy); err != nil {
+			return store, fmt.Errorf("parsing %s: %v", path, err)
+		}
+		if legacy.AccessToken != "" {
+			store.Profiles[defaultProfile] = legacy
+		}
+	}
+
+	// A store sealed with the legacy KDF is sealed again with its new key
+	if envelope.Encrypted != nil && store.key.kdf != envelope.Encrypted.KDF {
+		if err := saveTokenStore(p, &store); err != nil {
+			return store, fmt.Errorf("upgrading the encryption of %s: %v", path, err)
+		}
+		p.printSuccess("Upgraded the encryption of your saved credentials to " + storeKDF)
+	}
+
+	if store.key == nil {
+		settings, err := loadSettings()
+		if err != nil {
+			return store, err
+		}
+		if settings.EncryptCredentials {
+			p.printInfo("Encrypting your saved Slack credentials...")
+			if err := saveTokenStore(p, &store); err != nil {
+				return store, fmt.Errorf("encrypting %s: %v", path, err)
+			}
+			p.printSuccess("Saved credentials are now encrypted")
+		}
+	}
+
+	return store, nil
+}
+
+// saveTokenStore writes token.json, replacing the old file atomically so an
+// interrupted write never loses every profile at once. With
+// encrypt_credentials on, the file is sealed with the passphrase key.
+func saveTokenStore(p *prompter, store *tokenStore) error {
+	path, err := configPath(configFile)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	settings, err := loadSettings()
+	if err != nil {
+		return err
+	}
+	if settings.EncryptCredentials {
+		if store.key == nil {
+			key, err := newStoreKey(p)
+			if err != nil {
+				return err
+			}
+			store.key = &key
+		}
+
+		sealed, err := sealStore(*store.key, data)
+		if err != nil {
+			return err
+		}
+		if data, err = json.MarshalIndent(storeEnvelope{Encrypted: sealed}, "", "  "); err != nil {
+			return err
+		}
+	}
+
+	return writeFileAtomic(path, data, 0600)
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// into place, creating the config directory if needed
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// readTokenConfig reads the credentials of a profile ("" for the default)
+func readTokenConfig(p *prompter, profile string) (TokenConfig, error) {
+	store, err := loadTokenStore(p)
+	if err != nil {
+		return TokenConfig{}, err
+	}
+
+	name := store.resolve(profile)
+	config, ok := store.Profiles[name]
+	if !ok {
+		return config, fmt.Errorf("profile %q not found", name)
+	}
+	return config, nil
+}
+
+// saveTokenConfig stores the credentials of a profile ("" for the default).
+// The first profile ever saved becomes the default.
+func saveTokenConfig(p *prompter, profile string, config TokenConfig) error {
+	store, err := loadTokenStore(p)
+	if err != nil {
+		return err
+	}
+
+	name := store.resolve(profile)
+	store.Profiles[name] = config
+	if store.Default == "" {
+		store.Default = name
+	}
+
+	return saveTokenStore(p, &store)
+}
+
+const profileUsageText = `Usage: standup profile <command> [name]
+
+Commands:
+  list            List the saved profiles
+  add <name>      Sign in to a workspace and save it as a new profile
+                  (takes login's --port and --headless)
+  remove <name>   Delete a profile and its stored credentials
+  default <name>  Use this profile when --profile is not given
+`
+
+// runProfile implements `standup profile list|add|remove|default`
+func runProfile(p *prompter, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(p.writer, profileUsageText)
+		return flag.ErrHelp
+	}
+
+	command, args := args[0], args[1:]
+	if command == "add" {
+		return addProfile(p, args)
+	}
+	if command == "list" {
+		if len(args) != 0 {
+			return fmt.Errorf("profile list takes no arguments")
+		}
+		return listProfiles(p)
+	}
+
+	if len(args) != 1 {
+		fmt.Fprint(p.writer, profileUsageText)
+		return fmt.Errorf("profile %s needs exactly one profile name", command)
+	}
+	name := args[0]
+	if !validProfileName.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q (use letters, digits, '.', '_' and '-')", name)
+	}
+
+	switch command {
+	case "remove":
+		return removeProfile(p, name)
+	case "default":
+		return setDefaultProfile(p, name)
+	default:
+		fmt.Fprint(p.writer, profileUsageText)
+		return fmt.Errorf("unknown profile command %q", command)
+	}
+}
+
+// listProfiles prints every profile, marking the default one
+func listProfiles(p *prompter) error {
+	store, err := loadTokenStore(p)
+	if err != nil {
+		return err
+	}
+
+	if len(store.Profiles) == 0 {
+		p.printInfo("No profiles saved yet. Run `standup profile add <name>` to sign in.")
+		return nil
+	}
+
+	names := make([]string, 0, len(store.Profiles))
+	for name := range store.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	defaultName := store.resolve("")
+	for _, name := range names {
+		config := store.Profiles[name]
+		marker := "  "
+		if name == defaultName {
+			marker = "* "
+		}
+		fmt.Fprintf(p.writer, "%s%s\tteam: %s\tuser: %s\n", marker, name, orUnknown(config.TeamID), orUnknown(config.UserID))
+	}
+	return nil
+}
+
+// addProfile implements `standup profile add`: sign in to Slack and save the
+// result under a new name, with the same sign-in flags as login
+func addProfile(p *prompter, args []string) error {
+	var opts loginOptions
+
+	fs := flag.NewFlagSet("profile add", flag.ContinueOnError)
+	opts.addFlags(fs)
+	fs.Usage = func() {
+		printFlagUsage(fs.Output(), "standup profile add [flags] <name>", fs)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	// Flags may also follow the name
+	var name string
+	if fs.NArg() > 0 {
+		name = fs.Arg(0)
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return err
+		}
+	}
+	if name == "" {
+		fs.Usage()
+		return fmt.Errorf("profile add needs exactly one profile name")
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	if !validProfileName.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q (use letters, digits, '.', '_' and '-')", name)
+	}
+
+	store, err := loadTokenStore(p)
+	if err != nil {
+		return err
+	}
+	if _, exists := store.Profiles[name]; exists {
+		return fmt.Errorf("profile %q already exists, remove it first to sign in again", name)
+	}
+
+	if _, err := signIn(p, name, TokenConfig{}, opts); err != nil {
+		return err
+	}
+
+	p.printSuccess(fmt.Sprintf("Profile %q saved", name))
+	return nil
+}
+
+// removeProfile deletes a profile; if it was the default, no default is left
+func removeProfile(p *prompter, name string) error {
+	store, err := loadTokenStore(p)
+	if err != nil {
+		return err
+	}
+	if _, exists := store.Profiles[name]; !exists {
+		return fmt.Errorf("profile %q not found", name)
+	}
+
+	delete(store.Profiles, name)
+	if store.Default == name {
+		store.Default = ""
+	}
+
+	if err := saveTokenStore(p, &store); err != nil {
+		return err
+	}
+
+	p.printSuccess(fmt.Sprintf("Profile %q removed", name))
+	return nil
+}
+
+// setDefaultProfile marks an existing profile as the default
+func setDefaultProfile(p *prompter, name string) error {
+	store, err := loadTokenStore(p)
+	if err != nil {
+		return err
+	}
+	if _, exists := store.Profiles[name]; !exists {
+		return fmt.Errorf("profile %q not found", name)
+	}
+
+	store.Default = name
+	if err := saveTokenStore(p, &store); err != nil {
+		return err
+	}
+
+	p.printSuccess(fmt.Sprintf("Profile %q is now the default", name))
+	return nil
+}
+
+// checkProfileExists rejects an explicitly named profile that was never
+// added, rather than silently signing in under a mistyped name
+func checkProfileExists(p *prompter, name string) error {
+	if name == "" {
+		return nil
+	}
+
+	store, err := loadTokenStore(p)
+	if err != nil {
+		return err
+	}
+	if _, exists := store.Profiles[name]; !exists {
+		return fmt.Errorf("profile %q not found, add it with `standup profile add %s`", name, name)
+	}
+	return nil
+}
+
+// orUnknown shows a placeholder for IDs that were never recorded
+func orUnknown(value string) string {
+	if value == "" {
+		return "?"
+	}
+	return value
+}
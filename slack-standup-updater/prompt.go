@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// errNoInput is returned when input ends before a prompt is answered
+var errNoInput = errors.New("unexpected end of input")
+
+// prompter owns the terminal for the whole run. Every prompt reads from the
+// same buffered reader, so piped or scripted input is never swallowed by a
+// reader that belongs to an earlier prompt.
+type prompter struct {
+	reader *bufio.Reader
+	writer io.Writer
+	inFd   int  // Terminal file descriptor of the input, -1 when not a terminal
+	outTTY bool // Whether the output is a terminal that can be redrawn
+}
+
+// newPrompter creates a prompter reading answers from in and writing to out
+func newPrompter(in io.Reader, out io.Writer) *prompter {
+	p := &prompter{
+		reader: bufio.NewReader(in),
+		writer: out,
+		inFd:   -1,
+	}
+	if f, ok := in.(*os.File); ok && isTerminal(int(f.Fd())) {
+		p.inFd = int(f.Fd())
+	}
+	if f, ok := out.(*os.File); ok && isTerminal(int(f.Fd())) {
+		p.outTTY = true
+	}
+	return p
+}
+
+// interactive reports whether both ends are a terminal, so full screen
+// widgets like the picker can be used instead of plain prompts
+func (p *prompter) interactive() bool {
+	return p.inFd >= 0 && p.outTTY
+}
+
+// printInfo prints formatted informational messages
+func (p *prompter) printInfo(message string) {
+	if useColors {
+		fmt.Fprintf(p.writer, "%s%s==> %s%s\n", colorCyan, colorBold, message, colorReset)
+	} else {
+		fmt.Fprintln(p.writer, "==> "+message)
+	}
+}
+
+// printQuestion prints a formatted question
+func (p *prompter) printQuestion(message string) {
+	if useColors {
+		fmt.Fprintf(p.writer, "\n%s%s❓ %s%s\n", colorBlue, colorBold, message, colorReset)
+	} else {
+		fmt.Fprintf(p.writer, "\n❓ %s\n", message)
+	}
+}
+
+// printPrompt prints a prompt for user input
+func (p *prompter) printPrompt(message string) {
+	if useColors {
+		fmt.Fprintf(p.writer, "%s%s👉 %s%s ", colorYellow, colorBold, message, colorReset)
+	} else {
+		fmt.Fprintf(p.writer, "👉 %s ", message)
+	}
+}
+
+// printSuccess prints a success message
+func (p *prompter) printSuccess(message string) {
+	if useColors {
+		fmt.Fprintf(p.writer, "%s%s✅ %s%s\n", colorGreen, colorBold, message, colorReset)
+	} else {
+		fmt.Fprintf(p.writer, "✅ SUCCESS: %s\n", message)
+	}
+}
+
+// printError prints an error message
+func (p *prompter) printError(message string) {
+	if useColors {
+		fmt.Fprintf(p.writer, "%s%s❌ Error: %s%s\n", colorRed, colorBold, message, colorReset)
+	} else {
+		fmt.Fprintf(p.writer, "❌ ERROR: %s\n", message)
+	}
+}
+
+// printHeader prints a section header
+func (p *prompter) printHeader(message string) {
+	if useColors {
+		fmt.Fprintf(p.writer, "\n%s%s🔹 === %s ===%s\n", colorMagenta, colorBold, message, colorReset)
+	} else {
+		fmt.Fprintf(p.writer, "\n🔹 === %s ===\n", message)
+	}
+}
+
+// printDivider prints a divider line
+func (p *prompter) printDivider() {
+	if useColors {
+		fmt.Fprintf(p.writer, "%s%s✨ ----------------------------------------- ✨%s\n", colorWhite, colorBold, colorReset)
+	} else {
+		fmt.Fprintln(p.writer, "✨ ----------------------------------------- ✨")
+	}
+}
+
+// readLine reads a single trimmed line. A final line without a trailing
+// newline is still returned; errNoInput is only reported once nothing is left.
+func (p *prompter) readLine() (string, error) {
+	line, err := p.reader.ReadString('\n')
+	if err != nil {
+		if err != io.EOF {
+			return "", fmt.Errorf("reading input: %v", err)
+		}
+		if line == "" {
+			return "", errNoInput
+		}
+	}
+
+	return strings.TrimSpace(line), nil
+}
+
+// choose prints a prompt marker and reads a single line answer
+func (p *prompter) choose() (string, error) {
+	p.printPrompt(">")
+	return p.readLine()
+}
+
+// getInput prompts the user for input
+func (p *prompter) getInput(prompt string) (string, error) {
+	p.printInfo(prompt + ":")
+	return p.choose()
+}
+
+// getSecret prompts for input without echoing it when reading from a terminal
+func (p *prompter) getSecret(prompt string) (string, error) {
+	p.printInfo(prompt + ":")
+	p.printPrompt(">")
+
+	if p.inFd >= 0 {
+		if state, err := disableEcho(p.inFd); err == nil {
+			defer func() {
+				restoreTerminal(p.inFd, state)
+				fmt.Fprintln(p.writer)
+			}()
+		}
+	}
+
+	return p.readLine()
+}
+
+// askQuestion prompts the user with a question and returns the answer.
+// Required questions are asked again until at least one line is given.
+func (p *prompter) askQuestion(q Question) (string, error) {
+	return p.answerQuestion(q, "", false)
+}
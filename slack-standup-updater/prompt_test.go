@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// TestPipedStandup runs a whole scripted standup through runPost: "m" to
+// message yourself, then the answer to every default question
+func TestPipedStandup(t *testing.T) {
+	var posted url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/auth.test":
+			fmt.Fprint(w, `{"ok":true,"user_id":"U0123456789","team_id":"T0123456789"}`)
+		case "/conversations.open":
+			fmt.Fprint(w, `{"ok":true,"channel":{"id":"D0123456789"}}`)
+		case "/chat.postMessage":
+			if err := r.ParseForm(); err != nil {
+				t.Errorf("parsing chat.postMessage: %v", err)
+			}
+			posted = r.PostForm
+			fmt.Fprint(w, `{"ok":true,"channel":"D0123456789","ts":"1700000000.000100"}`)
+		case "/conversations.info":
+			fmt.Fprint(w, `{"ok":true,"channel":{"id":"D0123456789","is_im":true}}`)
+		case "/chat.getPermalink":
+			fmt.Fprint(w, `{"ok":true,"channel":"D0123456789","permalink":"https://example.slack.com/archives/D0123456789/p1700000000000100"}`)
+		default:
+			t.Errorf("unexpected API call %s", r.URL.Path)
+			fmt.Fprint(w, `{"ok":false,"error":"unknown_method"}`)
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("SLACK_TOKEN", "xoxp-test")
+	useSlackAPI(t, srv.URL+"/")
+
+	var out bytes.Buffer
+	input := strings.NewReader("m\nfoo\n\nbar\n\n\n")
+	p := newPrompter(input, &out)
+	if err := runPost(p, nil); err != nil {
+		t.Fatalf("runPost() error = %v\noutput:\n%s", err, out.String())
+	}
+
+	if posted == nil {
+		t.Fatalf("runPost() did not call chat.postMessage\noutput:\n%s", out.String())
+	}
+	if got := posted.Get("channel"); got != "D0123456789" {
+		t.Errorf("chat.postMessage channel = %q, want %q", got, "D0123456789")
+	}
+	want := formatStandupMessage(defaultQuestions, map[string]string{"yesterday": "foo", "today": "bar"})
+	if got := posted.Get("text"); got != want {
+		t.Errorf("chat.postMessage text = %q, want %q", got, want)
+	}
+
+	// Every line went to a prompt, none was left over or swallowed
+	if input.Len() != 0 {
+		t.Errorf("%d bytes of input left after the standup", input.Len())
+	}
+}
+
+// useSlackAPI points the Slack client at a fake API for the rest of the test
+func useSlackAPI(t *testing.T, apiURL string) {
+	t.Helper()
+	oldURL, oldIdentity := slackAPIURL, sessionIdentity
+	slackAPIURL = apiURL
+	t.Cleanup(func() { slackAPIURL, sessionIdentity = oldURL, oldIdentity })
+}
+
+func TestReadLine(t *testing.T) {
+	p := newPrompter(strings.NewReader("  first \nlast without newline"), &bytes.Buffer{})
+
+	for _, want := range []string{"first", "last without newline"} {
+		line, err := p.readLine()
+		if err != nil || line != want {
+			t.Errorf("readLine() = %q, %v, want %q, nil", line, err, want)
+		}
+	}
+	if _, err := p.readLine(); err != errNoInput {
+		t.Errorf("readLine() at the end = %v, want errNoInput", err)
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// draftFile keeps a standup that was saved instead of posted
+const draftFile = "draft.json"
+
+// reviewAction is what the user decided after seeing the preview
+type reviewAction int
+
+const (
+	reviewPost reviewAction = iota
+	reviewEdit
+	reviewDestination
+	reviewDraft
+	reviewCancel
+)
+
+// Draft is a standup saved for later
+type Draft struct {
+	Answers map[string]string `json:"answers"` // Keyed by question ID
+	Saved   int64             `json:"saved"`
+}
+
+// previewStandup shows where the standup will go and what it will look like
+func previewStandup(p *prompter, dir *directory, channelID, threadTS, message string) {
+	p.printHeader("Review 👀")
+
+	to := dir.conversationName(channelID)
+	if threadTS != "" {
+		if parent := threadParent(dir.api, channelID, threadTS); parent != "" {
+			to += fmt.Sprintf(", replying to %q", parent)
+		} else {
+			to += ", replying in thread " + threadTS
+		}
+	}
+	p.printInfo("To: " + to)
+
+	p.printDivider()
+	fmt.Fprint(p.writer, renderMrkdwn(message))
+	p.printDivider()
+}
+
+// chooseReviewAction asks what to do with the previewed standup
+func chooseReviewAction(p *prompter) (reviewAction, error) {
+	p.printInfo("[p]ost, [e]dit, change [d]estination, [s]ave draft or [c]ancel?")
+	for {
+		answer, err := p.choose()
+		if err != nil {
+			return reviewCancel, err
+		}
+
+		switch strings.ToLower(answer) {
+		case "p", "post":
+			return reviewPost, nil
+		case "e", "edit":
+			return reviewEdit, nil
+		case "d", "destination":
+			return reviewDestination, nil
+		case "s", "save", "draft":
+			return reviewDraft, nil
+		case "c", "cancel":
+			return reviewCancel, nil
+		}
+		p.printError("Enter p, e, d, s or c")
+	}
+}
+
+// editSection lets the user change the answer to one question
+func editSection(p *prompter, questions []Question, answers map[string]string) error {
+	p.printInfo("Which section do you want to edit?")
+	for i, q := range questions {
+		// The default prompts are numbered already
+		p.printInfo(fmt.Sprintf("%d. %s", i+1, promptNumberRe.ReplaceAllString(q.Prompt, "")))
+	}
+
+	var q Question
+	for {
+		answer, err := p.choose()
+		if err != nil {
+			return err
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(questions) {
+			q = questions[n-1]
+			break
+		}
+		p.printError(fmt.Sprintf("Enter a number from 1 to %d", len(questions)))
+	}
+
+	// The current bullet points come back to be edited, not typed again
+	answer, err := p.answerQuestion(q, answers[q.ID], false)
+	if err != nil {
+		return err
+	}
+	answers[q.ID] = answer
+	return nil
+}
+
+// conversationName names a channel for people, from the cache when it is
+// there and from conversations.info otherwise; the ID is the last resort
+func (d *directory) conversationName(channelID string) string {
+	if team, err := d.team(); err == nil {
+		for _, channel := range team.Channels {
+			if channel.ID == channelID {
+				return "#" + channel.Name
+			}
+		}
+		for _, dm := range team.DMs {
+			if dm.ID == channelID {
+				return dm.Name
+			}
+		}
+	}
+
+	info, err := d.api.GetConversationInfo(&slack.GetConversationInfoInput{ChannelID: channelID})
+	if err != nil {
+		return channelID
+	}
+	if info.IsIM {
+		return "direct message " + channelID
+	}
+	return "#" + info.Name
+}
+
+// threadParent returns the first line of a thread's parent message, or ""
+// when it cannot be read
+func threadParent(api *slack.Client, channelID, threadTS string) string {
+	history, err := api.GetConversationHistory(&slack.GetConversationHistoryParameters{
+		ChannelID: channelID,
+		Latest:    threadTS,
+		Oldest:    threadTS,
+		Inclusive: true,
+		Limit:     1,
+	})
+	if err != nil || len(history.Messages) == 0 {
+		return ""
+	}
+
+	line, _, _ := strings.Cut(history.Messages[0].Text, "\n")
+	return truncate(line, 60)
+}
+
+// promptNumberRe matches the "1. " a prompt may start with
+var promptNumberRe = regexp.MustCompile(`^\d+[.)]\s*`)
+
+var (
+	mrkdwnLinkRe   = regexp.MustCompile(`<(https?://[^|>]+)\|([^>]+)>`)
+	mrkdwnURLRe    = regexp.MustCompile(`<(https?://[^|>]+)>`)
+	mrkdwnBoldRe   = regexp.MustCompile(`(^|[\s(])\*([^*\n]+)\*`)
+	mrkdwnItalicRe = regexp.MustCompile(`(^|[\s(])_([^_\n]+)_`)
+	mrkdwnStrikeRe = regexp.MustCompile(`(^|[\s(])~([^~\n]+)~`)
+	mrkdwnCodeRe   = regexp.MustCompile("`([^`\n]+)`")
+)
+
+// renderMrkdwn approximates how Slack will show a message: *bold*,
+// _italic_, ~strike~, `code` and <url|text> links, with each bullet point
+// starting with a bullet
+func renderMrkdwn(text string) string {
+	text = mrkdwnLinkRe.ReplaceAllString(text, "$2 ($1)")
+	text = mrkdwnURLRe.ReplaceAllString(text, "$1")
+
+	if useColors {
+		text = mrkdwnCodeRe.ReplaceAllString(text, colorCyan+"$1"+colorReset)
+		text = mrkdwnBoldRe.ReplaceAllString(text, "$1"+colorBold+"$2"+colorReset)
+		text = mrkdwnItalicRe.ReplaceAllString(text, "$1\033[3m$2"+colorReset)
+		text = mrkdwnStrikeRe.ReplaceAllString(text, "$1\033[9m$2"+colorReset)
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ") {
+			lines[i] = "  • " + line[2:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// loadDraft returns the saved draft, or nil when there is none
+func loadDraft() (*Draft, error) {
+	path, err := configPath(draftFile)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var draft Draft
+	if err := json.Unmarshal(data, &draft); err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", path, err)
+	}
+	return &draft, nil
+}
+
+// saveDraft keeps the answers for the next run
+func saveDraft(draft Draft) error {
+	path, err := configPath(draftFile)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(draft, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0600)
+}
+
+// clearDraft removes the saved draft once it has been posted
+func clearDraft() error {
+	path, err := configPath(draftFile)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -7,7 +7,7 @@
 cd "$(dirname "$0")"
 
 # Run the Go program directly
-go run main.go
+go run . "$@"
 
 # Exit with the same status as the Go program
 exit $? 
\ No newline at end of file
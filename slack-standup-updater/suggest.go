@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Providers of suggested bullet points, named by a question's suggest
+const (
+	suggestGitCommits  = "git-commits"  // Commits since the previous standup
+	suggestGitBranches = "git-branches" // Branches and worktrees with work in progress
+)
+
+// suggestProviders are the valid values of suggest
+var suggestProviders = map[string]bool{
+	suggestGitCommits:  true,
+	suggestGitBranches: true,
+}
+
+// suggestBullets adds to prefill what the provider of each question with
+// suggest set comes up with. With ask, the user picks which suggestions to
+// keep; otherwise all of them go into the editor to be trimmed there.
+// Questions in skip are answered already; profile is the profile posting.
+func suggestBullets(p *prompter, settings Settings, profile string, skip map[string]bool, prefill map[string]string, ask bool) error {
+	for _, q := range settings.Questions {
+		if q.Suggest == "" || skip[q.ID] {
+			continue
+		}
+
+		var suggestions []string
+		var title string
+		var err error
+		switch q.Suggest {
+		case suggestGitCommits:
+			if !settings.Git.configured() {
+				continue
+			}
+			title = "Your Commits 🛠️"
+			suggestions, err = gitCommitSuggestions(settings.Git, profile, time.Now())
+		case suggestGitBranches:
+			if !settings.Git.configured() {
+				continue
+			}
+			title = "Work in Progress 🚧"
+			suggestions, err = gitBranchSuggestions(settings.Git, time.Now())
+		}
+		if err != nil {
+			p.printInfo(fmt.Sprintf("Warning: Could not gather suggestions for %q: %v", q.Prompt, err))
+			continue
+		}
+		if len(suggestions) == 0 {
+			continue
+		}
+
+		if ask {
+			if suggestions, err = pickSuggestions(p, title, suggestions); err != nil {
+				return err
+			}
+		}
+		if len(suggestions) == 0 {
+			continue
+		}
+		if prefill[q.ID] != "" {
+			suggestions = append([]string{prefill[q.ID]}, suggestions...)
+		}
+		prefill[q.ID] = strings.Join(suggestions, "\n")
+	}
+	return nil
+}
+
+// pickSuggestions lists suggested bullet points and returns the ones chosen
+func pickSuggestions(p *prompter, title string, suggestions []string) ([]string, error) {
+	p.printHeader(title)
+	for i, suggestion := range suggestions {
+		p.printInfo(fmt.Sprintf("%d. %s", i+1, suggestion))
+	}
+	p.printInfo("Add which to your answer? [a]ll, [n]one, or numbers like 1,3 (Enter for all)")
+
+	for {
+		answer, err := p.choose()
+		if err != nil {
+			return nil, err
+		}
+
+		switch strings.ToLower(answer) {
+		case "", "a", "all":
+			return suggestions, nil
+		case "n", "none":
+			return nil, nil
+		}
+
+		var picked []string
+		valid := true
+		for _, field := range strings.FieldsFunc(answer, func(r rune) bool { return r == ',' || r == ' ' }) {
+			n, err := strconv.Atoi(field)
+			if err != nil || n < 1 || n > len(suggestions) {
+				valid = false
+				break
+			}
+			picked = append(picked, suggestions[n-1])
+		}
+		if valid && len(picked) > 0 {
+			return picked, nil
+		}
+		p.printError(fmt.Sprintf("Enter a, n, or numbers from 1 to %d", len(suggestions)))
+	}
+}
@@ -0,0 +1,39 @@
+//go:build !linux && !darwin
+
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+// terminalState is a saved terminal mode to restore later
+type terminalState struct{}
+
+var errTerminalUnsupported = errors.New("terminal control is not supported on this platform")
+
+// isTerminal reports whether fd is an interactive terminal; terminal modes
+// are not supported here, so input is always treated as plain lines
+func isTerminal(fd int) bool {
+	return false
+}
+
+// disableEcho is not supported on this platform
+func disableEcho(fd int) (*terminalState, error) {
+	return nil, errTerminalUnsupported
+}
+
+// enableRawMode is not supported on this platform
+func enableRawMode(fd int) (*terminalState, error) {
+	return nil, errTerminalUnsupported
+}
+
+// setReadTimeout is not supported on this platform
+func setReadTimeout(fd int, timeout time.Duration) error {
+	return errTerminalUnsupported
+}
+
+// restoreTerminal is not supported on this platform
+func restoreTerminal(fd int, state *terminalState) error {
+	return errTerminalUnsupported
+}
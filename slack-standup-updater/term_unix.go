@@ -0,0 +1,96 @@
+//go:build linux || darwin
+
+package main
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// terminalState is a saved terminal mode to restore later
+type terminalState struct {
+	termios syscall.Termios
+}
+
+func getTermios(fd int) (*syscall.Termios, error) {
+	termios := &syscall.Termios{}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), ioctlGetTermios, uintptr(unsafe.Pointer(termios))); errno != 0 {
+		return nil, errno
+	}
+	return termios, nil
+}
+
+func setTermios(fd int, termios *syscall.Termios) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), ioctlSetTermios, uintptr(unsafe.Pointer(termios))); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// isTerminal reports whether fd is an interactive terminal
+func isTerminal(fd int) bool {
+	_, err := getTermios(fd)
+	return err == nil
+}
+
+// disableEcho stops the terminal from echoing typed characters while still
+// delivering whole lines, for reading secrets
+func disableEcho(fd int) (*terminalState, error) {
+	termios, err := getTermios(fd)
+	if err != nil {
+		return nil, err
+	}
+	old := &terminalState{termios: *termios}
+
+	termios.Lflag &^= syscall.ECHO
+	termios.Lflag |= syscall.ICANON | syscall.ISIG
+	if err := setTermios(fd, termios); err != nil {
+		return nil, err
+	}
+	return old, nil
+}
+
+// enableRawMode delivers every key press as it is typed, without echo or
+// line editing, for the picker and the line editor. Ctrl-C arrives as a key, so
+// the caller can restore the terminal before giving up.
+func enableRawMode(fd int) (*terminalState, error) {
+	termios, err := getTermios(fd)
+	if err != nil {
+		return nil, err
+	}
+	old := &terminalState{termios: *termios}
+
+	termios.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	termios.Iflag &^= syscall.ICRNL | syscall.IXON
+	termios.Cc[syscall.VMIN] = 1
+	termios.Cc[syscall.VTIME] = 0
+	if err := setTermios(fd, termios); err != nil {
+		return nil, err
+	}
+	return old, nil
+}
+
+// setReadTimeout makes reads in raw mode return with nothing once timeout
+// passes without input, or block until a key arrives again with 0. The
+// terminal counts in tenths of a second.
+func setReadTimeout(fd int, timeout time.Duration) error {
+	termios, err := getTermios(fd)
+	if err != nil {
+		return err
+	}
+
+	if timeout > 0 {
+		termios.Cc[syscall.VMIN] = 0
+		termios.Cc[syscall.VTIME] = uint8((timeout + 99*time.Millisecond) / (100 * time.Millisecond))
+	} else {
+		termios.Cc[syscall.VMIN] = 1
+		termios.Cc[syscall.VTIME] = 0
+	}
+	return setTermios(fd, termios)
+}
+
+// restoreTerminal puts back a mode saved by disableEcho or enableRawMode
+func restoreTerminal(fd int, state *terminalState) error {
+	return setTermios(fd, &state.termios)
+}
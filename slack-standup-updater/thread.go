@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// autoThread as a thread means "today's standup thread", found in the channel
+const autoThread = "auto"
+
+const (
+	// defaultThreadMatch is used when neither an author nor a pattern is given
+	defaultThreadMatch = `(?i)standup`
+
+	// defaultThreadText is the parent message posted when no thread is found;
+	// {date} is replaced with today's date
+	defaultThreadText = "Standup for {date}"
+)
+
+// ThreadFinder says how to recognise the day's standup thread among the
+// messages of a channel
+type ThreadFinder struct {
+	Author   string `json:"author,omitempty"`   // User (@handle, name, email or ID) or bot ID that starts the thread
+	Match    string `json:"match,omitempty"`    // Regular expression the message text must match
+	Timezone string `json:"timezone,omitempty"` // IANA time zone that decides when today starts (default: local time)
+	Create   string `json:"create,omitempty"`   // Text of the parent message to offer when none is found
+}
+
+// validate checks the pattern and time zone up front, so a typo in
+// config.json is reported before anything is asked
+func (f ThreadFinder) validate() error {
+	if _, err := regexp.Compile(f.pattern()); err != nil {
+		return fmt.Errorf("invalid match pattern: %v", err)
+	}
+	if _, err := f.location(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// pattern returns the regular expression the parent message must match
+func (f ThreadFinder) pattern() string {
+	if f.Match == "" && f.Author == "" {
+		return defaultThreadMatch
+	}
+	return f.Match
+}
+
+// location returns the time zone that today is counted in
+func (f ThreadFinder) location() (*time.Location, error) {
+	if f.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(f.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", f.Timezone)
+	}
+	return loc, nil
+}
+
+// parentText returns the text for a new parent message for the given day
+func (f ThreadFinder) parentText(day time.Time) string {
+	text := f.Create
+	if text == "" {
+		text = defaultThreadText
+	}
+	return strings.ReplaceAll(text, "{date}", day.Format("Monday, January 2"))
+}
+
+// startOfDay returns midnight of the day t falls on, in t's time zone
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// findStandupThread looks through today's messages in a channel, newest
+// first, for a parent message matching the finder and reports whether one
+// was found.
+func findStandupThread(api *slack.Client, channelID string, finder ThreadFinder, now time.Time) (slack.Message, bool, error) {
+	re, err := regexp.Compile(finder.pattern())
+	if err != nil {
+		return slack.Message{}, false, fmt.Errorf("invalid match pattern: %v", err)
+	}
+	loc, err := finder.location()
+	if err != nil {
+		return slack.Message{}, false, err
+	}
+
+	params := &slack.GetConversationHistoryParameters{
+		ChannelID: channelID,
+		Oldest:    strconv.FormatInt(startOfDay(now.In(loc)).Unix(), 10) + ".000000",
+		Limit:     200,
+	}
+
+	for {
+		history, err := api.GetConversationHistory(params)
+		if err != nil {
+			return slack.Message{}, false, fmt.Errorf("reading channel history: %v%s", err, scopeHint(err, "channels:history and groups:history"))
+		}
+
+		// Messages come newest first
+		for _, msg := range history.Messages {
+			// Replies shown in the channel are not thread parents
+			if msg.SubType == "thread_broadcast" || (msg.ThreadTimestamp != "" && msg.ThreadTimestamp != msg.Timestamp) {
+				continue
+			}
+			if finder.Author != "" && msg.User != finder.Author && msg.BotID != finder.Author {
+				continue
+			}
+			if !re.MatchString(msg.Text) {
+				continue
+			}
+			return msg, true, nil
+		}
+
+		if !history.HasMore || history.ResponseMetaData.NextCursor == "" {
+			return slack.Message{}, false, nil
+		}
+		params.Cursor = history.ResponseMetaData.NextCursor
+	}
+}
+
+// discoverThread finds today's standup thread in a channel. When there is
+// none yet, it offers to post the parent message and reply to that.
+func discoverThread(p *prompter, api *slack.Client, channelID string, finder ThreadFinder) (string, error) {
+	p.printInfo(fmt.Sprintf("Looking for today's standup thread in %s... 🔍", channelID))
+
+	now := time.Now()
+	msg, found, err := findStandupThread(api, channelID, finder, now)
+	if err != nil {
+		return "", err
+	}
+	if found {
+		title, _, _ := strings.Cut(msg.Text, "\n")
+		p.printSuccess(fmt.Sprintf("Found today's standup thread: %q", title))
+		return msg.Timestamp, nil
+	}
+
+	loc, err := finder.location()
+	if err != nil {
+		return "", err
+	}
+	text := finder.parentText(now.In(loc))
+
+	p.printInfo("No standup thread for today was found.")
+	p.printInfo(fmt.Sprintf("Start one with the message %q? (y/n)", text))
+	answer, err := p.choose()
+	if err != nil {
+		return "", err
+	}
+	if strings.ToLower(answer) != "y" && strings.ToLower(answer) != "yes" {
+		return "", fmt.Errorf("no standup thread for today in %s", channelID)
+	}
+
+	_, threadTS, err := api.PostMessage(channelID,
+		slack.MsgOptionText(text, false),
+		slack.MsgOptionAsUser(true),
+	)
+	if err != nil {
+		return "", fmt.Errorf("posting the thread message: %v", err)
+	}
+	p.printSuccess("Started today's standup thread")
+	return threadTS, nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// slackAPIURL is where Web API methods are called; tests point it at a fake
+var slackAPIURL = slack.APIURL
+
+// tokenCommandTimeout bounds how long a token_command may take, e.g. while a
+// password manager waits for its own unlock
+const tokenCommandTimeout = 2 * time.Minute
+
+// authInfo is what auth.test tells us about a token
+type authInfo struct {
+	UserID string
+	TeamID string
+	Scopes string
+}
+
+// authTest checks a token against auth.test and reports who it belongs to.
+// The granted scopes only come back in the X-OAuth-Scopes header, which the
+// slack client does not expose, so the call is made directly.
+func authTest(token string) (authInfo, error) {
+	var info authInfo
+
+	req, err := http.NewRequest(http.MethodPost, slackAPIURL+"auth.test", nil)
+	if err != nil {
+		return info, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return info, fmt.Errorf("auth.test request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return info, fmt.Errorf("error reading response: %v", err)
+	}
+
+	var result struct {
+		Ok     bool   `json:"ok"`
+		Error  string `json:"error,omitempty"`
+		UserID string `json:"user_id"`
+		TeamID string `json:"team_id"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return info, fmt.Errorf("error parsing auth.test response: %v", err)
+	}
+	if !result.Ok {
+		return info, fmt.Errorf("token rejected by Slack: %s", result.Error)
+	}
+
+	info.UserID = result.UserID
+	info.TeamID = result.TeamID
+	info.Scopes = resp.Header.Get("X-OAuth-Scopes")
+	return info, nil
+}
+
+// sessionIdentity is who this run's token belongs to. A token from
+// SLACK_TOKEN is never saved, so this is the only place its user, team and
+// scopes are kept.
+var sessionIdentity *authInfo
+
+// identity returns the user and team the client is signed in as, calling
+// auth.test only when this run does not know them yet
+func identity(api *slack.Client) (authInfo, error) {
+	if sessionIdentity != nil && sessionIdentity.UserID != "" && sessionIdentity.TeamID != "" {
+		return *sessionIdentity, nil
+	}
+	info, err := api.AuthTest()
+	if err != nil {
+		return authInfo{}, err
+	}
+	sessionIdentity = &authInfo{UserID: info.UserID, TeamID: info.TeamID}
+	return *sessionIdentity, nil
+}
+
+// rememberIdentity keeps the user, team and scopes recorded for a token
+func rememberIdentity(config TokenConfig) {
+	sessionIdentity = &authInfo{UserID: config.UserID, TeamID: config.TeamID, Scopes: config.Scopes}
+}
+
+// validateToken makes sure a token works and is a user token, since a bot
+// token would post as the app instead of as the user
+func validateToken(token string) (authInfo, error) {
+	if strings.HasPrefix(token, "xoxb-") {
+		return authInfo{}, fmt.Errorf("this is a bot token (xoxb-); a user token (xoxp-) is needed to post as yourself")
+	}
+	return authTest(token)
+}
+
+// runTokenCommand runs a profile's token_command through the shell and
+// returns the token it prints. The token is only ever kept in memory.
+func runTokenCommand(p *prompter, command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+
+	var stdout bytes.Buffer
+	// A terminal lets password managers ask for their own unlock; piped input
+	// holds the standup's answers, read through the prompter alone
+	if p.inFd >= 0 {
+		cmd.Stdin = os.Stdin
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("running token command: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return "", fmt.Errorf("token command failed: %v", err)
+		}
+	case <-time.After(tokenCommandTimeout):
+		cmd.Process.Kill()
+		return "", fmt.Errorf("token command did not finish within %v", tokenCommandTimeout)
+	}
+
+	// Commands like `pass show` print the secret on the first line
+	token, _, _ := strings.Cut(strings.TrimSpace(stdout.String()), "\n")
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", fmt.Errorf("token command printed nothing")
+	}
+	return token, nil
+}
+
+// tokenFromCommand gets a fresh token from the profile's token_command and
+// keeps the recorded user, team and scopes up to date
+func tokenFromCommand(p *prompter, profile string, config TokenConfig) (string, error) {
+	p.printInfo("Getting token from token command 🔑")
+
+	token, err := runTokenCommand(p, config.TokenCommand)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := validateToken(token)
+	if err != nil {
+		return "", err
+	}
+	sessionIdentity = &info
+
+	if info.UserID != config.UserID || info.TeamID != config.TeamID || info.Scopes != config.Scopes {
+		config.UserID, config.TeamID, config.Scopes = info.UserID, info.TeamID, info.Scopes
+		if err := saveTokenConfig(p, profile, config); err != nil {
+			p.printInfo(fmt.Sprintf("Warning: Could not save profile: %v", err))
+		}
+	}
+
+	return token, nil
+}
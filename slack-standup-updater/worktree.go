@@ -0,0 +1,256 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// wipStaleAfter is how long a branch can sit untouched before it no longer
+// counts as work in progress, unless it is checked out or has changes
+const wipStaleAfter = 30 * 24 * time.Hour
+
+var (
+	trackAheadRe  = regexp.MustCompile(`ahead (\d+)`)
+	trackBehindRe = regexp.MustCompile(`behind (\d+)`)
+
+	// e.g. "WIP on fix-login: 1a2b3c4 Retry" or "On fix-login: half done"
+	stashBranchRe = regexp.MustCompile(`^(?:WIP on|On) ([^:]+):`)
+)
+
+// branchState is what is in progress on one branch of a repository
+type branchState struct {
+	repo       string
+	branch     string // Empty for a detached worktree
+	head       string // Abbreviated commit, for a detached worktree
+	subject    string // Of the last commit
+	committed  time.Time
+	upstream   string
+	ahead      int // Commits not pushed to the upstream, or not on a default branch without one
+	behind     int
+	changed    int // Uncommitted files in the worktrees it is checked out in
+	stashes    int
+	checkedOut bool
+}
+
+// inProgress reports whether the branch has anything left to do. Branches
+// untouched for a while only count with changes or stashes.
+func (b branchState) inProgress(now time.Time) bool {
+	if b.changed > 0 || b.stashes > 0 || (b.checkedOut && !defaultBranches[b.branch]) {
+		return true
+	}
+	if now.Sub(b.committed) >= wipStaleAfter {
+		return false
+	}
+	// A default branch behind its upstream only needs a pull
+	return b.ahead > 0 || (b.behind > 0 && !defaultBranches[b.branch])
+}
+
+// bullet describes the branch and why it is in progress
+func (b branchState) bullet() string {
+	name := b.branch
+	if name == "" {
+		name = "detached at " + b.head
+	}
+
+	var details []string
+	if b.ahead > 0 {
+		if b.upstream != "" {
+			details = append(details, plural(b.ahead, "unpushed commit"))
+		} else {
+			details = append(details, plural(b.ahead, "commit")+", not pushed")
+		}
+	}
+	if b.behind > 0 {
+		details = append(details, fmt.Sprintf("%d behind %s", b.behind, b.upstream))
+	}
+	if b.changed > 0 {
+		details = append(details, plural(b.changed, "changed file"))
+	}
+	if b.stashes > 0 {
+		details = append(details, plural(b.stashes, "stash"))
+	}
+
+	text := fmt.Sprintf("%s (%s)", filepath.Base(b.repo), name)
+	if b.subject != "" {
+		text += ": " + b.subject
+	}
+	if len(details) > 0 {
+		text += " [" + strings.Join(details, ", ") + "]"
+	}
+	return text
+}
+
+// plural writes a count with its noun, adding "s" or "es" for more than one
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	if strings.HasSuffix(noun, "sh") {
+		return fmt.Sprintf("%d %ses", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// gitBranchSuggestions returns a bullet point per branch with work in
+// progress in the configured repositories. Only local git data is read;
+// ahead and behind are as of the last fetch.
+func gitBranchSuggestions(git GitSettings, now time.Time) ([]string, error) {
+	repos, err := findRepos(git)
+	if err != nil {
+		return nil, err
+	}
+
+	var bullets []string
+	for _, repo := range repos {
+		states, err := repoBranchStates(repo)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", repo, err)
+		}
+		for _, state := range states {
+			if state.inProgress(now) {
+				bullets = append(bullets, state.bullet())
+			}
+		}
+	}
+	return bullets, nil
+}
+
+// repoBranchStates gathers the state of every local branch of a repository
+// and of detached worktrees, most recently committed first
+func repoBranchStates(repo string) ([]branchState, error) {
+	features, defaults, err := localBranches(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := runGit(repo, "for-each-ref", "--format=%(refname:short)%1f%(upstream:short)%1f%(upstream:track)%1f%(committerdate:unix)%1f%(contents:subject)", "refs/heads")
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]*branchState)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.SplitN(line, "\x1f", 5)
+		if len(fields) != 5 {
+			continue
+		}
+		unix, _ := strconv.ParseInt(fields[3], 10, 64)
+		state := &branchState{repo: repo, branch: fields[0], upstream: fields[1], subject: fields[4], committed: time.Unix(unix, 0)}
+		if match := trackAheadRe.FindStringSubmatch(fields[2]); match != nil {
+			state.ahead, _ = strconv.Atoi(match[1])
+		}
+		if match := trackBehindRe.FindStringSubmatch(fields[2]); match != nil {
+			state.behind, _ = strconv.Atoi(match[1])
+		}
+		// "[gone]" means the upstream was deleted, e.g. after a merge
+		if fields[2] == "[gone]" {
+			state.upstream = ""
+		}
+		states[state.branch] = state
+	}
+
+	// Without an upstream, a feature branch's own commits are unpushed
+	for _, branch := range features {
+		state := states[branch]
+		if state == nil || state.upstream != "" || len(defaults) == 0 {
+			continue
+		}
+		args := []string{"rev-list", "--count", "refs/heads/" + branch}
+		for _, base := range defaults {
+			args = append(args, "^refs/heads/"+base)
+		}
+		count, err := runGit(repo, append(args, "--")...)
+		if err != nil {
+			return nil, err
+		}
+		state.ahead, _ = strconv.Atoi(strings.TrimSpace(count))
+	}
+
+	detached, err := addWorktreeChanges(repo, states)
+	if err != nil {
+		return nil, err
+	}
+	if err := addStashes(repo, states); err != nil {
+		return nil, err
+	}
+
+	result := detached
+	for _, state := range states {
+		result = append(result, *state)
+	}
+	sort.SliceStable(result, func(a, b int) bool { return result[a].committed.After(result[b].committed) })
+	return result, nil
+}
+
+// addWorktreeChanges marks the branches checked out in each worktree and
+// counts their uncommitted files. Detached worktrees with changes are
+// returned on their own.
+func addWorktreeChanges(repo string, states map[string]*branchState) ([]branchState, error) {
+	out, err := runGit(repo, "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+
+	var detached []branchState
+	for _, block := range strings.Split(strings.TrimSpace(out), "\n\n") {
+		var path, head, branch string
+		for _, line := range strings.Split(block, "\n") {
+			key, value, _ := strings.Cut(line, " ")
+			switch key {
+			case "worktree":
+				path = value
+			case "HEAD":
+				head = value
+			case "branch":
+				branch = strings.TrimPrefix(value, "refs/heads/")
+			case "bare", "prunable":
+				path = ""
+			}
+		}
+		if path == "" {
+			continue
+		}
+
+		// --no-optional-locks keeps status from refreshing the index, so
+		// looking changes nothing
+		status, err := runGit(path, "--no-optional-locks", "status", "--porcelain")
+		if err != nil {
+			return nil, err
+		}
+		changed := 0
+		if status = strings.TrimSpace(status); status != "" {
+			changed = len(strings.Split(status, "\n"))
+		}
+
+		if state := states[branch]; state != nil {
+			state.checkedOut = true
+			state.changed += changed
+			continue
+		}
+		if changed > 0 && len(head) >= 7 {
+			subject, _ := runGit(path, "log", "-1", "--format=%s")
+			detached = append(detached, branchState{repo: repo, head: head[:7], subject: strings.TrimSpace(subject), changed: changed, committed: time.Now()})
+		}
+	}
+	return detached, nil
+}
+
+// addStashes counts the stashes made on each branch
+func addStashes(repo string, states map[string]*branchState) error {
+	out, err := runGit(repo, "stash", "list", "--format=%gs")
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if match := stashBranchRe.FindStringSubmatch(line); match != nil {
+			if state := states[match[1]]; state != nil {
+				state.stashes++
+			}
+		}
+	}
+	return nil
+}